@@ -3,11 +3,11 @@ package helper
 import (
 	"bytes"
 	"fmt"
+	"github.com/zbitech/common/pkg/model/config"
 	"github.com/zbitech/common/pkg/model/entity"
 	"github.com/zbitech/common/pkg/model/spec"
 	"github.com/zbitech/common/pkg/model/ztypes"
 	"github.com/zbitech/common/pkg/utils"
-	"github.com/zbitech/common/pkg/vars"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -85,6 +85,11 @@ func CreateProjectLabels(project *entity.Project) map[string]string {
 	}
 }
 
+// CreateServiceAccountName returns the name of the service account the workloads of a project run as.
+func CreateServiceAccountName(project string) string {
+	return fmt.Sprintf("%s-workload", project)
+}
+
 func CreateInstanceLabels(instance entity.InstanceIF) map[string]string {
 	return map[string]string{
 		"platform": "zbi",
@@ -95,16 +100,16 @@ func CreateInstanceLabels(instance entity.InstanceIF) map[string]string {
 	}
 }
 
-func CreateEnvoySpec(envoyServicePort int32) spec.EnvoySpec {
+func CreateEnvoySpec(envoy config.EnvoyConfig, accessAuthorization bool, envoyServicePort int32) spec.EnvoySpec {
 	return spec.EnvoySpec{
-		Image:                 vars.AppConfig.Envoy.Image,
-		Command:               utils.MarshalObject(vars.AppConfig.Envoy.Command),
+		Image:                 envoy.Image,
+		Command:               utils.MarshalObject(envoy.Command),
 		Port:                  envoyServicePort,
-		Timeout:               vars.AppConfig.Envoy.Timeout,
-		AccessAuthorization:   vars.AppConfig.Features.AccessAuthorizationEnabled,
-		AuthServerURL:         vars.AppConfig.Envoy.AuthServerURL,
-		AuthServerPort:        vars.AppConfig.Envoy.AuthServerPort,
-		AuthenticationEnabled: vars.AppConfig.Envoy.AuthenticationEnabled,
+		Timeout:               envoy.Timeout,
+		AccessAuthorization:   accessAuthorization,
+		AuthServerURL:         envoy.AuthServerURL,
+		AuthServerPort:        envoy.AuthServerPort,
+		AuthenticationEnabled: envoy.AuthenticationEnabled,
 	}
 }
 
@@ -107,3 +107,8 @@ func Test_EncodeJSON(t *testing.T) {
 	assert.NoErrorf(t, err, "Failed to generate JSON from object - %s", err)
 	assert.NotNilf(t, data, "Failed to convert to JSON")
 }
+
+func Test_CreateServiceAccountName(t *testing.T) {
+	assert.Equal(t, "project-workload", CreateServiceAccountName("project"))
+	assert.NotEqual(t, CreateServiceAccountName("project1"), CreateServiceAccountName("project2"))
+}
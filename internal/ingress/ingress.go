@@ -0,0 +1,213 @@
+// Package ingress reconciles the includes and routes of Contour HTTPProxy objects.
+//
+// Includes and routes are treated as sets keyed by what Contour uses to tell them apart: the namespace
+// and name of an include and the condition prefixes of a route. Adding an entry replaces any entry with
+// the same key, deleting a missing entry does nothing, and entries are written in key order, so the
+// resulting proxy only depends on the last event applied for each key.
+package ingress
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Entry is an include or a route as it appears in the spec of an HTTPProxy.
+type Entry map[string]interface{}
+
+// Operation is what an event does to an entry.
+type Operation int
+
+const (
+	Upsert Operation = iota
+	Delete
+)
+
+// OperationFor maps resource events to entry operations. Deletion removes the entry; every other event
+// (create, update, start, stop, ...) renders the entry again and replaces it.
+func OperationFor(action ztypes.EventAction) Operation {
+	if action == ztypes.EventActionDelete {
+		return Delete
+	}
+	return Upsert
+}
+
+// Kind selects the part of the proxy spec an entry belongs to.
+type Kind struct {
+	field string
+	key   func(Entry) (string, error)
+}
+
+var (
+	Include = Kind{field: "includes", key: includeKey}
+	Route   = Kind{field: "routes", key: routeKey}
+)
+
+// Proxy is an HTTPProxy whose includes and routes are held as keyed sets.
+type Proxy struct {
+	object  *unstructured.Unstructured
+	entries map[string]map[string]Entry
+}
+
+// Load prepares an existing proxy for reconciliation. Fields maintained by the API server that cannot
+// be sent back in an update are removed from the object.
+func Load(object *unstructured.Unstructured) (*Proxy, error) {
+	if object == nil {
+		return nil, fmt.Errorf("no proxy to reconcile")
+	}
+
+	// a JSON round trip copies the object and converts entries that were set as typed values, which
+	// DeepCopy cannot handle, into JSON-compatible maps
+	data, err := json.Marshal(object.Object)
+	if err != nil {
+		return nil, err
+	}
+	object = &unstructured.Unstructured{}
+	if err = json.Unmarshal(data, &object.Object); err != nil {
+		return nil, err
+	}
+
+	unstructured.RemoveNestedField(object.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(object.Object, "status")
+
+	var proxy = Proxy{object: object, entries: make(map[string]map[string]Entry)}
+	for _, kind := range []Kind{Include, Route} {
+		entries, err := readEntries(object, kind.field)
+		if err != nil {
+			return nil, err
+		}
+
+		proxy.entries[kind.field] = make(map[string]Entry, len(entries))
+		for _, entry := range entries {
+			key, err := kind.key(entry)
+			if err != nil {
+				return nil, err
+			}
+			proxy.entries[kind.field][key] = entry
+		}
+	}
+
+	return &proxy, nil
+}
+
+// Apply adds, replaces or removes entry.
+func (p *Proxy) Apply(kind Kind, entry Entry, operation Operation) error {
+	entry, err := normalize(entry)
+	if err != nil {
+		return err
+	}
+
+	key, err := kind.key(entry)
+	if err != nil {
+		return err
+	}
+
+	if operation == Delete {
+		delete(p.entries[kind.field], key)
+	} else {
+		p.entries[kind.field][key] = entry
+	}
+
+	return nil
+}
+
+// Entries returns the entries of kind in key order.
+func (p *Proxy) Entries(kind Kind) []Entry {
+	var keys = make([]string, 0, len(p.entries[kind.field]))
+	for key := range p.entries[kind.field] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries = make([]Entry, len(keys))
+	for index, key := range keys {
+		entries[index] = p.entries[kind.field][key]
+	}
+	return entries
+}
+
+// Object returns the proxy with its includes and routes written in key order. Empty sets are omitted.
+func (p *Proxy) Object() *unstructured.Unstructured {
+	object := p.object.DeepCopy()
+	for _, kind := range []Kind{Include, Route} {
+		entries := p.Entries(kind)
+		if len(entries) == 0 {
+			unstructured.RemoveNestedField(object.Object, "spec", kind.field)
+			continue
+		}
+
+		var values = make([]interface{}, len(entries))
+		for index, entry := range entries {
+			values[index] = map[string]interface{}(entry)
+		}
+		_ = unstructured.SetNestedSlice(object.Object, values, "spec", kind.field)
+	}
+	return object
+}
+
+// DecodeEntry parses an include or route rendered by a template.
+func DecodeEntry(data string) (Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func readEntries(object *unstructured.Unstructured, field string) ([]Entry, error) {
+	spec, ok := object.Object["spec"].(map[string]interface{})
+	if !ok || spec[field] == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(spec[field])
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("spec.%s is not a list of objects - %s", field, err)
+	}
+	return entries, nil
+}
+
+// normalize gives entry the JSON-compatible types used by unstructured objects.
+func normalize(entry Entry) (Entry, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeEntry(string(data))
+}
+
+func includeKey(entry Entry) (string, error) {
+	name, _ := entry["name"].(string)
+	namespace, _ := entry["namespace"].(string)
+	if len(name) == 0 || len(namespace) == 0 {
+		return "", fmt.Errorf("include requires a name and a namespace")
+	}
+	return namespace + "/" + name, nil
+}
+
+func routeKey(entry Entry) (string, error) {
+	conditions, _ := entry["conditions"].([]interface{})
+
+	var prefixes []string
+	for _, condition := range conditions {
+		if values, ok := condition.(map[string]interface{}); ok {
+			if prefix, ok := values["prefix"].(string); ok {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+
+	if len(prefixes) == 0 {
+		return "", fmt.Errorf("route requires a prefix condition")
+	}
+	return strings.Join(prefixes, ","), nil
+}
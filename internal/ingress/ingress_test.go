@@ -0,0 +1,143 @@
+package ingress
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// event adds or deletes one of a few includes or routes, so that generated sequences often touch the
+// same entry more than once.
+type event struct {
+	kind      Kind
+	entry     Entry
+	operation Operation
+}
+
+type events []event
+
+func (events) Generate(r *rand.Rand, size int) reflect.Value {
+	var generated = make(events, r.Intn(size+1))
+	for index := range generated {
+		key, version := r.Intn(4), r.Intn(3)
+
+		var e = event{operation: Operation(r.Intn(2))}
+		if r.Intn(2) == 0 {
+			e.kind = Include
+			e.entry = Entry{"name": "project-proxy", "namespace": fmt.Sprintf("project%d", key),
+				"conditions": []interface{}{map[string]interface{}{"prefix": fmt.Sprintf("/project%d/v%d", key, version)}}}
+		} else {
+			e.kind = Route
+			e.entry = Entry{"conditions": []interface{}{map[string]interface{}{"prefix": fmt.Sprintf("/instance%d/v1", key)}},
+				"services": []interface{}{map[string]interface{}{"name": fmt.Sprintf("svc-%d", key), "port": 8080 + version}}}
+		}
+		generated[index] = e
+	}
+	return reflect.ValueOf(generated)
+}
+
+func sampleProxy() *unstructured.Unstructured {
+	var proxy unstructured.Unstructured
+	proxy.SetAPIVersion("projectcontour.io/v1")
+	proxy.SetKind("HTTPProxy")
+	proxy.SetNamespace("zbi")
+	proxy.SetName("zbi-proxy")
+	return &proxy
+}
+
+func apply(t *testing.T, object *unstructured.Unstructured, sequence ...event) *unstructured.Unstructured {
+	proxy, err := Load(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range sequence {
+		if err = proxy.Apply(e.kind, e.entry, e.operation); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return proxy.Object()
+}
+
+// lastPerKey keeps the last event of each entry, which is all the resulting proxy may depend on.
+func lastPerKey(t *testing.T, sequence events) events {
+	var last = make(map[string]event)
+	for _, e := range sequence {
+		key, err := e.kind.key(e.entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last[e.kind.field+":"+key] = e
+	}
+
+	var reduced events
+	for _, e := range last {
+		reduced = append(reduced, e)
+	}
+	return reduced
+}
+
+func Test_ApplyIdempotent(t *testing.T) {
+	property := func(sequence events) bool {
+		var repeated events
+		for _, e := range sequence {
+			repeated = append(repeated, e, e)
+		}
+		return reflect.DeepEqual(apply(t, sampleProxy(), sequence...), apply(t, sampleProxy(), repeated...))
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func Test_ApplyConverges(t *testing.T) {
+	property := func(sequence events, seed int64) bool {
+		reduced := lastPerKey(t, sequence)
+		rand.New(rand.NewSource(seed)).Shuffle(len(reduced), func(i, j int) {
+			reduced[i], reduced[j] = reduced[j], reduced[i]
+		})
+		return reflect.DeepEqual(apply(t, sampleProxy(), sequence...), apply(t, sampleProxy(), reduced...))
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func Test_ApplyReload(t *testing.T) {
+	property := func(first, second events) bool {
+		reloaded := apply(t, apply(t, sampleProxy(), first...), second...)
+		return reflect.DeepEqual(apply(t, sampleProxy(), append(append(events{}, first...), second...)...), reloaded)
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func Test_Load(t *testing.T) {
+	_, err := Load(nil)
+	assert.Error(t, err)
+
+	object := sampleProxy()
+	object.Object["status"] = map[string]interface{}{"currentStatus": "valid"}
+	object.Object["spec"] = map[string]interface{}{"routes": []map[string]interface{}{
+		{"conditions": []map[string]interface{}{{"prefix": "/instance/v1"}}},
+	}}
+
+	proxy, err := Load(object)
+	assert.NoError(t, err)
+	assert.Len(t, proxy.Entries(Route), 1)
+	assert.Empty(t, proxy.Entries(Include))
+	assert.NotContains(t, proxy.Object().Object, "status")
+
+	assert.Error(t, proxy.Apply(Route, Entry{"services": []interface{}{}}, Upsert))
+	assert.Error(t, proxy.Apply(Include, Entry{"name": "project-proxy"}, Upsert))
+
+	assert.NoError(t, proxy.Apply(Route, Entry{"conditions": []interface{}{map[string]interface{}{"prefix": "/instance/v1"}}}, Delete))
+	_, found, _ := unstructured.NestedSlice(proxy.Object().Object, "spec", "routes")
+	assert.False(t, found)
+}
+
+func Test_OperationFor(t *testing.T) {
+	assert.Equal(t, Delete, OperationFor(ztypes.EventActionDelete))
+	assert.Equal(t, Upsert, OperationFor(ztypes.EventActionCreate))
+	assert.Equal(t, Upsert, OperationFor(ztypes.EventActionStopInstance))
+}
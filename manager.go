@@ -2,9 +2,12 @@ package mgr
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/zbitech/common/pkg/logger"
-	"github.com/zbitech/common/pkg/vars"
 
 	"github.com/zbitech/common/interfaces"
 	"github.com/zbitech/common/pkg/errs"
@@ -13,62 +16,109 @@ import (
 )
 
 type ResourceManagerFactory struct {
-	ingressManager     interfaces.AppResourceManagerIF
-	projectManager     interfaces.ProjectResourceManagerIF
-	zcashManager       interfaces.InstanceResourceManagerIF
-	lightWalletManager interfaces.InstanceResourceManagerIF
+	ingressManager   interfaces.AppResourceManagerIF
+	projectManager   interfaces.ProjectResourceManagerIF
+	instanceManagers map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF
+	reloadMu         sync.Mutex
+	opts             rsc.Options
 }
 
-func NewResourceManagerFactory() interfaces.ResourceManagerFactoryIF {
-	return &ResourceManagerFactory{}
+// NewResourceManagerFactory creates a factory whose managers render assets with opts. The factory
+// is used as opts.Factory when none is provided.
+func NewResourceManagerFactory(opts rsc.Options) interfaces.ResourceManagerFactoryIF {
+	var factory = &ResourceManagerFactory{opts: opts}
+	if factory.opts.Factory == nil {
+		factory.opts.Factory = factory
+	}
+	return factory
 }
 
 func (m *ResourceManagerFactory) Init(ctx context.Context) error {
 
 	var err error
 
-	zcashCfg, ok := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	if !ok {
-		return errors.New("unable to retrieve zcash configuration")
-	}
+	m.instanceManagers = make(map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF)
 
-	m.zcashManager, err = rsc.NewZcashInstanceResourceManager(zcashCfg)
-	if err != nil {
-		logger.Errorf(ctx, "Failed to create zcash resource manager - %s", err)
-		return errs.ErrInstanceResourceFailed
-	}
+	var unknownTypes []string
+	for _, instanceCfg := range m.opts.ResourceConfig.Instances {
+		iType := instanceCfg.Type
+
+		constructor, ok := getInstanceResourceManagerConstructor(iType)
+		if !ok {
+			unknownTypes = append(unknownTypes, string(iType))
+			continue
+		}
 
-	lwdCfg, ok := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeLWD)
-	if !ok {
-		return errors.New("unable to retrieve lightwalletd configuration")
+		if _, ok = m.instanceManagers[iType]; ok {
+			return fmt.Errorf("instance type %s is configured more than once", iType)
+		}
+
+		iCfg, ok := m.opts.ResourceConfig.GetInstanceResourceConfig(iType)
+		if !ok {
+			return fmt.Errorf("unable to retrieve %s configuration", iType)
+		}
+
+		m.instanceManagers[iType], err = constructor(iCfg, m.opts)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to create %s resource manager - %s", iType, err)
+			return errs.ErrInstanceResourceFailed
+		}
 	}
 
-	m.lightWalletManager, err = rsc.NewLWDInstanceResourceManager(lwdCfg)
-	if err != nil {
-		logger.Errorf(ctx, "Failed to create lightwalletd resource manager - %s", err)
-		return errs.ErrInstanceResourceFailed
+	if len(unknownTypes) > 0 {
+		logger.Errorf(ctx, "Resource config contains unregistered instance types %v - registered types are %v", unknownTypes, RegisteredInstanceTypes())
+		return fmt.Errorf("unknown instance types in resource config: %s", strings.Join(unknownTypes, ", "))
 	}
 
-	m.projectManager, err = rsc.NewProjectResourceManager(vars.ResourceConfig.Project,
-		map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF{
-			ztypes.InstanceTypeZCASH: m.zcashManager,
-			ztypes.InstanceTypeLWD:   m.lightWalletManager,
-		})
+	m.projectManager, err = rsc.NewProjectResourceManager(m.opts.ResourceConfig.Project, m.instanceManagers, m.opts)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to create project resource manager - %s", err)
 		return errs.ErrProjectResourceFailed
 	}
 
-	logger.Infof(ctx, "Initializing App Manager with %v and %v", vars.ResourceConfig.App, m.projectManager)
-	m.ingressManager, err = rsc.NewAppResourceManager(vars.ResourceConfig.App, m.projectManager)
+	logger.Infof(ctx, "Initializing App Manager with %v and %v", m.opts.ResourceConfig.App, m.projectManager)
+	m.ingressManager, err = rsc.NewAppResourceManager(m.opts.ResourceConfig.App, m.projectManager, m.opts)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to create ingress resource manager - %s", err)
 		return errs.ErrIngressResourceFailed
 	}
 
+	if report := m.VerifyTemplates(ctx); len(report) > 0 {
+		logger.Errorf(ctx, "Resource templates do not satisfy the manager contracts - %s", report)
+		return report
+	}
+
 	return nil
 }
 
+// VerifyTemplates dry-renders the templates of every manager for each configured version and
+// reports every key an operation needs that is missing or does not produce a decodable object.
+func (m *ResourceManagerFactory) VerifyTemplates(ctx context.Context) rsc.TemplateReport {
+	var report rsc.TemplateReport
+
+	var iTypes = make([]string, 0, len(m.instanceManagers))
+	for iType := range m.instanceManagers {
+		iTypes = append(iTypes, string(iType))
+	}
+	sort.Strings(iTypes)
+
+	for _, iType := range iTypes {
+		if verifier, ok := m.instanceManagers[ztypes.InstanceType(iType)].(rsc.TemplateVerifier); ok {
+			report = append(report, verifier.VerifyTemplates(ctx)...)
+		}
+	}
+
+	if verifier, ok := m.projectManager.(rsc.TemplateVerifier); ok {
+		report = append(report, verifier.VerifyTemplates(ctx)...)
+	}
+
+	if verifier, ok := m.ingressManager.(rsc.TemplateVerifier); ok {
+		report = append(report, verifier.VerifyTemplates(ctx)...)
+	}
+
+	return report
+}
+
 func (m *ResourceManagerFactory) GetAppResourceManager(ctx context.Context) interfaces.AppResourceManagerIF {
 	return m.ingressManager
 }
@@ -76,3 +126,8 @@ func (m *ResourceManagerFactory) GetAppResourceManager(ctx context.Context) inte
 func (m *ResourceManagerFactory) GetProjectDataManager(ctx context.Context) interfaces.ProjectResourceManagerIF {
 	return m.projectManager
 }
+
+func (m *ResourceManagerFactory) GetInstanceResourceManager(ctx context.Context, iType ztypes.InstanceType) (interfaces.InstanceResourceManagerIF, bool) {
+	iManager, ok := m.instanceManagers[iType]
+	return iManager, ok
+}
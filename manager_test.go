@@ -2,20 +2,31 @@ package mgr
 
 import (
 	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/zbitech/common/factory"
-	"testing"
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/mgr/rsc"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func Test_NewResourceManagerFactory(t *testing.T) {
-	var f = NewResourceManagerFactory()
+	var f = NewResourceManagerFactory(rsc.Options{})
 	assert.NotNilf(t, f, "Failed to create resource manager factory")
 }
 
 func Test_Init(t *testing.T) {
 	ctx := context.Background()
 	factory.InitProjectResourceConfig(ctx)
-	var f = NewResourceManagerFactory()
+	var f = NewResourceManagerFactory(testOptions())
 	err := f.Init(ctx)
 	assert.NoErrorf(t, err, "Error while initializing resource manager factory - %s", err)
 }
@@ -23,7 +34,7 @@ func Test_Init(t *testing.T) {
 func Test_GetIngressResourceManager(t *testing.T) {
 	ctx := context.Background()
 	factory.InitProjectResourceConfig(ctx)
-	var f = NewResourceManagerFactory()
+	var f = NewResourceManagerFactory(testOptions())
 	f.Init(ctx)
 	assert.NotNilf(t, f.GetAppResourceManager(ctx), "Failed to create ingress resource manager")
 }
@@ -31,7 +42,100 @@ func Test_GetIngressResourceManager(t *testing.T) {
 func Test_GetProjectDataManager(t *testing.T) {
 	ctx := context.Background()
 	factory.InitProjectResourceConfig(ctx)
-	var f = NewResourceManagerFactory()
+	var f = NewResourceManagerFactory(testOptions())
 	f.Init(ctx)
 	assert.NotNilf(t, f.GetProjectDataManager(ctx), "Failed to create project resource manager")
 }
+
+func Test_GetInstanceResourceManager(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+	var f = NewResourceManagerFactory(testOptions())
+	f.Init(ctx)
+
+	rf := f.(*ResourceManagerFactory)
+	for _, iType := range []ztypes.InstanceType{ztypes.InstanceTypeZCASH, ztypes.InstanceTypeLWD} {
+		iManager, ok := rf.GetInstanceResourceManager(ctx, iType)
+		assert.Truef(t, ok, "Failed to create %s resource manager", iType)
+		assert.NotNilf(t, iManager, "Failed to create %s resource manager", iType)
+	}
+}
+
+func Test_IndependentFactories(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	opts1 := testOptions()
+	opts2 := testOptions()
+	opts2.Policy.Domain = "api.other.local"
+	opts2.Assets = replaceAsset(t, vars.ASSET_PATH_DIRECTORY, "templates/project_templates_v1.tmpl",
+		"automountServiceAccountToken: false", "automountServiceAccountToken: true")
+
+	var f1 = NewResourceManagerFactory(opts1)
+	assert.NoError(t, f1.Init(ctx))
+	var f2 = NewResourceManagerFactory(opts2)
+	assert.NoError(t, f2.Init(ctx))
+
+	assert.NotSame(t, f1.GetProjectDataManager(ctx), f2.GetProjectDataManager(ctx))
+	assert.NotSame(t, f1.GetAppResourceManager(ctx), f2.GetAppResourceManager(ctx))
+	assert.Equal(t, "api.other.local", f2.(*ResourceManagerFactory).opts.Policy.Domain)
+
+	assert.False(t, automountServiceAccount(ctx, t, f1), "Factory rendered the templates of another factory")
+	assert.True(t, automountServiceAccount(ctx, t, f2))
+}
+
+// automountServiceAccount renders the assets of a project with f and returns the automountServiceAccountToken
+// of its service account.
+func automountServiceAccount(ctx context.Context, t *testing.T, f interfaces.ResourceManagerFactoryIF) bool {
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+
+	projManager := f.GetProjectDataManager(ctx)
+	project, err := projManager.CreateProject(ctx, &request)
+	assert.NoError(t, err)
+
+	objects, err := projManager.CreateProjectAssets(ctx, project)
+	assert.NoError(t, err)
+	for _, obj := range objects {
+		if obj.GetKind() == "ServiceAccount" {
+			value, _, _ := unstructured.NestedBool(obj.Object, "automountServiceAccountToken")
+			return value
+		}
+	}
+
+	t.Fatal("Project assets have no service account")
+	return false
+}
+
+// replaceAsset returns the files under dir with old replaced by new in the file called name.
+func replaceAsset(t *testing.T, dir, name, old, new string) fstest.MapFS {
+	var assets = make(fstest.MapFS)
+	err := fs.WalkDir(os.DirFS(dir), ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(os.DirFS(dir), path)
+		if err != nil {
+			return err
+		}
+		if path == name {
+			assert.Contains(t, string(data), old)
+			data = []byte(strings.Replace(string(data), old, new, 1))
+		}
+		assets[path] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	assert.NoError(t, err)
+	return assets
+}
+
+// testOptions returns the default options completed with the configuration the test setup loads into vars.
+func testOptions() rsc.Options {
+	opts := rsc.DefaultOptions()
+	opts.ResourceConfig = vars.ResourceConfig
+	opts.Policy = vars.AppConfig.Policy
+	opts.Envoy = vars.AppConfig.Envoy
+	opts.Features = vars.AppConfig.Features
+	opts.Assets = os.DirFS(vars.ASSET_PATH_DIRECTORY)
+	return opts
+}
@@ -0,0 +1,67 @@
+package mgr
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/rsc"
+)
+
+// InstanceResourceManagerConstructor creates the resource manager for a single instance type
+// from its entry under "instances:" in the resource config.
+type InstanceResourceManagerConstructor func(cfg *config.InstanceResourceConfig, opts rsc.Options) (interfaces.InstanceResourceManagerIF, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[ztypes.InstanceType]InstanceResourceManagerConstructor)
+)
+
+func init() {
+	RegisterInstanceResourceManager(ztypes.InstanceTypeZCASH, rsc.NewZcashInstanceResourceManager)
+	RegisterInstanceResourceManager(ztypes.InstanceTypeLWD, rsc.NewLWDInstanceResourceManager)
+	RegisterInstanceResourceManager(rsc.InstanceTypeZEBRA, rsc.NewZebraInstanceResourceManager)
+}
+
+// RegisterInstanceResourceManager makes an instance resource manager available to
+// ResourceManagerFactory.Init for the given instance type. It panics if the constructor
+// is nil or the type has already been registered.
+func RegisterInstanceResourceManager(iType ztypes.InstanceType, constructor InstanceResourceManagerConstructor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if constructor == nil {
+		panic(fmt.Sprintf("mgr: nil constructor registered for instance type %s", iType))
+	}
+
+	if _, ok := registry[iType]; ok {
+		panic(fmt.Sprintf("mgr: instance type %s registered twice", iType))
+	}
+
+	registry[iType] = constructor
+}
+
+func getInstanceResourceManagerConstructor(iType ztypes.InstanceType) (InstanceResourceManagerConstructor, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	constructor, ok := registry[iType]
+	return constructor, ok
+}
+
+// RegisteredInstanceTypes returns the registered instance types in sorted order.
+func RegisteredInstanceTypes() []ztypes.InstanceType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var iTypes = make([]ztypes.InstanceType, 0, len(registry))
+	for iType := range registry {
+		iTypes = append(iTypes, iType)
+	}
+
+	sort.Slice(iTypes, func(i, j int) bool { return iTypes[i] < iTypes[j] })
+	return iTypes
+}
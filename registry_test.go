@@ -0,0 +1,24 @@
+package mgr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/rsc"
+	"testing"
+)
+
+func Test_RegisteredInstanceTypes(t *testing.T) {
+	iTypes := RegisteredInstanceTypes()
+	assert.Contains(t, iTypes, ztypes.InstanceTypeZCASH)
+	assert.Contains(t, iTypes, ztypes.InstanceTypeLWD)
+}
+
+func Test_RegisterInstanceResourceManager(t *testing.T) {
+	assert.Panicsf(t, func() {
+		RegisterInstanceResourceManager(ztypes.InstanceTypeZCASH, rsc.NewZcashInstanceResourceManager)
+	}, "Duplicate registration should panic")
+
+	assert.Panicsf(t, func() {
+		RegisterInstanceResourceManager(ztypes.InstanceType("unregistered"), nil)
+	}, "Nil constructor should panic")
+}
@@ -0,0 +1,185 @@
+package mgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/rsc"
+)
+
+const (
+	reloadDebounce = 500 * time.Millisecond
+)
+
+// ResourceConfigLoader re-reads the resource config (project.yaml) when the asset directory changes.
+type ResourceConfigLoader func(ctx context.Context) (*config.ResourceConfig, error)
+
+// Reload re-parses the templates of every manager from rscConfig and swaps them in only
+// when all of them are valid. On failure the versions currently in use are kept. Instance
+// types are only added or removed on restart, so a config that adds or removes one is rejected.
+func (m *ResourceManagerFactory) Reload(ctx context.Context, rscConfig *config.ResourceConfig) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	var configured = make(map[ztypes.InstanceType]bool, len(rscConfig.Instances))
+	for _, instanceCfg := range rscConfig.Instances {
+		if _, ok := m.instanceManagers[instanceCfg.Type]; !ok {
+			logger.Errorf(ctx, "Reloaded resource config adds %s - instance types are only added on restart", instanceCfg.Type)
+			return fmt.Errorf("instance type %s cannot be added by a reload", instanceCfg.Type)
+		}
+		configured[instanceCfg.Type] = true
+	}
+
+	var commits []func()
+
+	for iType, iManager := range m.instanceManagers {
+		if !configured[iType] {
+			logger.Errorf(ctx, "Reloaded resource config is missing %s - instance types are only removed on restart", iType)
+			return fmt.Errorf("instance type %s cannot be removed by a reload", iType)
+		}
+
+		reloader, ok := iManager.(rsc.InstanceResourceReloader)
+		if !ok {
+			logger.Infof(ctx, "Resource manager for %s does not support reloading", iType)
+			continue
+		}
+
+		iCfg, ok := rscConfig.GetInstanceResourceConfig(iType)
+		if !ok {
+			return fmt.Errorf("unable to retrieve %s configuration", iType)
+		}
+
+		commit, err := reloader.PrepareReload(ctx, iCfg)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to reload %s resources - %s", iType, err)
+			return fmt.Errorf("%s resources: %s", iType, err)
+		}
+		commits = append(commits, commit)
+
+		if reloader, ok := iManager.(rsc.ResourceConfigReloader); ok {
+			commit, err = reloader.PrepareResourceConfigReload(ctx, rscConfig)
+			if err != nil {
+				logger.Errorf(ctx, "Failed to reload %s resources - %s", iType, err)
+				return fmt.Errorf("%s resources: %s", iType, err)
+			}
+			commits = append(commits, commit)
+		}
+	}
+
+	if reloader, ok := m.projectManager.(rsc.ProjectResourceReloader); ok {
+		commit, err := reloader.PrepareReload(ctx, rscConfig.Project)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to reload project resources - %s", err)
+			return fmt.Errorf("project resources: %s", err)
+		}
+		commits = append(commits, commit)
+	}
+
+	if reloader, ok := m.ingressManager.(rsc.AppResourceReloader); ok {
+		commit, err := reloader.PrepareReload(ctx, rscConfig.App)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to reload app resources - %s", err)
+			return fmt.Errorf("app resources: %s", err)
+		}
+		commits = append(commits, commit)
+	}
+
+	for _, commit := range commits {
+		commit()
+	}
+	m.opts.ResourceConfig = rscConfig
+
+	logger.Infof(ctx, "Reloaded resource templates")
+	return nil
+}
+
+// Watch reloads the resource templates whenever a file under dir changes. dir should be the directory
+// that Options.Assets reads from. When loader is nil only the templates are re-parsed and the resource
+// config in use is kept. Watching stops when ctx is cancelled.
+func (m *ResourceManagerFactory) Watch(ctx context.Context, dir string, loader ResourceConfigLoader) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		var reload = make(chan struct{}, 1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+					continue
+				}
+
+				logger.Debugf(ctx, "Asset %s changed - %s", event.Name, event.Op)
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDebounce, func() {
+					select {
+					case reload <- struct{}{}:
+					default:
+					}
+				})
+
+			case <-reload:
+				m.reloadMu.Lock()
+				var rscConfig = m.opts.ResourceConfig
+				m.reloadMu.Unlock()
+				if loader != nil {
+					var loadErr error
+					if rscConfig, loadErr = loader(ctx); loadErr != nil {
+						logger.Errorf(ctx, "Failed to load resource config - %s", loadErr)
+						continue
+					}
+				}
+
+				if err := m.Reload(ctx, rscConfig); err != nil {
+					logger.Errorf(ctx, "Rejected resource changes - %s", err)
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Errorf(ctx, "Asset watcher failed - %s", err)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package mgr
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/mgr/rsc"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const projectTemplates = "templates/project_templates_v1.tmpl"
+
+func Test_Reload(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+	var f = NewResourceManagerFactory(testOptions())
+	assert.NoError(t, f.Init(ctx))
+
+	projResources, ok := f.GetProjectDataManager(ctx).GetProjectResources("v1")
+	assert.True(t, ok)
+
+	err := f.(*ResourceManagerFactory).Reload(ctx, vars.ResourceConfig)
+	assert.NoErrorf(t, err, "Failed to reload resources - %s", err)
+
+	reloaded, ok := f.GetProjectDataManager(ctx).GetProjectResources("v1")
+	assert.True(t, ok)
+	assert.NotSame(t, projResources, reloaded)
+}
+
+func Test_ReloadTemplates(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	dir := copyAssets(t, vars.ASSET_PATH_DIRECTORY)
+	opts := testOptions()
+	opts.Assets = os.DirFS(dir)
+
+	var f = NewResourceManagerFactory(opts)
+	assert.NoError(t, f.Init(ctx))
+	assert.False(t, automountServiceAccount(ctx, t, f))
+
+	rewriteAsset(t, dir, projectTemplates, "automountServiceAccountToken: false", "automountServiceAccountToken: true")
+	assert.NoError(t, f.(*ResourceManagerFactory).Reload(ctx, vars.ResourceConfig))
+	assert.True(t, automountServiceAccount(ctx, t, f), "Reload did not render the new template")
+
+	rewriteAsset(t, dir, projectTemplates, `{{define "NAMESPACE"}}`, `{{define "NAMESPACE"}}{{.Namespace`)
+	assert.Error(t, f.(*ResourceManagerFactory).Reload(ctx, vars.ResourceConfig))
+	assert.True(t, automountServiceAccount(ctx, t, f), "Broken template replaced the templates in use")
+}
+
+func Test_ReloadInstanceTypes(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	var removed = *vars.ResourceConfig
+	removed.Instances = removed.Instances[1:]
+
+	var f = NewResourceManagerFactory(testOptions())
+	assert.NoError(t, f.Init(ctx))
+	assert.Error(t, f.(*ResourceManagerFactory).Reload(ctx, &removed), "Reload removed an instance type")
+
+	opts := testOptions()
+	opts.ResourceConfig = &removed
+	f = NewResourceManagerFactory(opts)
+	assert.NoError(t, f.Init(ctx))
+	assert.Error(t, f.(*ResourceManagerFactory).Reload(ctx, vars.ResourceConfig), "Reload added an instance type")
+}
+
+func Test_ReloadZcashPort(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	var f = NewResourceManagerFactory(testOptions())
+	assert.NoError(t, f.Init(ctx))
+
+	zcashCfg, ok := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	assert.True(t, ok)
+	port := zcashCfg.Ports["service"]
+	assert.Contains(t, lwdZcashConf(ctx, t, f), fmt.Sprintf("rpcport=%d", port))
+
+	factory.InitProjectResourceConfig(ctx)
+	zcashCfg, _ = vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	zcashCfg.Ports["service"] = port + 1
+	defer func() { zcashCfg.Ports["service"] = port }()
+
+	assert.NoError(t, f.(*ResourceManagerFactory).Reload(ctx, vars.ResourceConfig))
+	assert.Contains(t, lwdZcashConf(ctx, t, f), fmt.Sprintf("rpcport=%d", port+1), "Reload did not change the zcash port of lightwalletd")
+}
+
+// lwdZcashConf returns the zcash.conf rendered for a lightwalletd server.
+func lwdZcashConf(ctx context.Context, t *testing.T, f interfaces.ResourceManagerFactoryIF) string {
+	lwdManager, ok := f.(*ResourceManagerFactory).GetInstanceResourceManager(ctx, ztypes.InstanceTypeLWD)
+	assert.True(t, ok)
+
+	var lwd = &rsc.LWDInstance{LWDInstance: entity.LWDInstance{
+		Instance:      entity.Instance{Project: "sample", Name: "wallet", Version: "v1", InstanceType: ztypes.InstanceTypeLWD},
+		ZcashInstance: "node",
+	}}
+	objects, err := lwdManager.CreateRotationAssets(ctx, lwd)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, objects)
+
+	conf, _, _ := unstructured.NestedString(objects[0].Object, "data", "zcash.conf")
+	return conf
+}
+
+func Test_Watch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	factory.InitProjectResourceConfig(ctx)
+
+	dir := copyAssets(t, vars.ASSET_PATH_DIRECTORY)
+	opts := testOptions()
+	opts.Assets = os.DirFS(dir)
+
+	var f = NewResourceManagerFactory(opts)
+	assert.NoError(t, f.Init(ctx))
+
+	err := f.(*ResourceManagerFactory).Watch(ctx, dir, nil)
+	assert.NoErrorf(t, err, "Failed to watch asset directory - %s", err)
+
+	rewriteAsset(t, dir, projectTemplates, "automountServiceAccountToken: false", "automountServiceAccountToken: true")
+	assert.Eventually(t, func() bool { return automountServiceAccount(ctx, t, f) }, 5*time.Second, 100*time.Millisecond,
+		"Changed template was not reloaded")
+
+	rewriteAsset(t, dir, projectTemplates, `{{define "NAMESPACE"}}`, `{{define "NAMESPACE"}}{{.Namespace`)
+	time.Sleep(4 * reloadDebounce)
+	assert.True(t, automountServiceAccount(ctx, t, f), "Broken template replaced the templates in use")
+}
+
+// copyAssets copies the files under dir into a temporary directory and returns it.
+func copyAssets(t *testing.T, dir string) string {
+	target := t.TempDir()
+	err := fs.WalkDir(os.DirFS(dir), ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(target, path), 0755)
+		}
+		data, err := fs.ReadFile(os.DirFS(dir), path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(target, path), data, 0644)
+	})
+	assert.NoError(t, err)
+	return target
+}
+
+// rewriteAsset replaces old with new in the file called name under dir.
+func rewriteAsset(t *testing.T, dir, name, old, new string) {
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), old)
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), old, new, 1)), 0644))
+}
@@ -8,34 +8,115 @@ import (
 	"github.com/zbitech/common/pkg/model/config"
 	"github.com/zbitech/common/pkg/model/object"
 	"github.com/zbitech/common/pkg/model/spec"
-	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/common/pkg/model/ztypes"
 	"github.com/zbitech/mgr/internal/helper"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sync"
+)
+
+var (
+	APP_TEMPLATES = TemplateContract{
+		volumeOperation:           {"VOLUME"},
+		snapshotOperation:         {"SNAPSHOT"},
+		snapshotScheduleOperation: {"SCHEDULE_SNAPSHOT"},
+	}
 )
 
 type AppResourceManager struct {
+	mu             sync.RWMutex
 	resourceConfig *config.AppResourceConfig
+	templates      map[string]*FileTemplate
 	projManager    interfaces.ProjectResourceManagerIF
+	opts           Options
 }
 
 func NewAppResourceManager(resourceConfig *config.AppResourceConfig,
-	projManager interfaces.ProjectResourceManagerIF) (interfaces.AppResourceManagerIF, error) {
-	var mgr = &AppResourceManager{
-		resourceConfig: resourceConfig,
+	projManager interfaces.ProjectResourceManagerIF, opts Options) (interfaces.AppResourceManagerIF, error) {
+	versions, templates, err := loadVersions(opts.Assets, resourceConfig.Versions, object.NO_FUNCS)
+	if err != nil {
+		return nil, err
+	}
+
+	var appConfig = *resourceConfig
+	appConfig.Versions = versions
+
+	return &AppResourceManager{
+		resourceConfig: &appConfig,
+		templates:      templates,
 		projManager:    projManager,
+		opts:           opts,
+	}, nil
+}
+
+func (app *AppResourceManager) config() *config.AppResourceConfig {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return app.resourceConfig
+}
+
+func (app *AppResourceManager) fileTemplate(version string) *FileTemplate {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return app.templates[version]
+}
+
+func (app *AppResourceManager) PrepareReload(ctx context.Context, cfg *config.AppResourceConfig) (func(), error) {
+	versions, templates, err := loadVersions(app.opts.Assets, cfg.Versions, object.NO_FUNCS)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, cfg := range mgr.resourceConfig.Versions {
-		if err := cfg.Init(vars.ASSET_PATH_DIRECTORY, object.NO_FUNCS); err != nil {
-			return nil, err
-		}
+	if err = app.verification().verify(versions, templates).Err(); err != nil {
+		return nil, err
 	}
 
-	return mgr, nil
+	var resourceConfig = *cfg
+	resourceConfig.Versions = versions
+
+	return func() {
+		app.mu.Lock()
+		defer app.mu.Unlock()
+		app.resourceConfig = &resourceConfig
+		app.templates = templates
+	}, nil
+}
+
+func (app *AppResourceManager) VerifyTemplates(ctx context.Context) TemplateReport {
+	app.mu.RLock()
+	versions, templates := app.resourceConfig.Versions, app.templates
+	app.mu.RUnlock()
+
+	return app.verification().verify(versions, templates)
+}
+
+func (app *AppResourceManager) verification() templateVerification {
+	return templateVerification{
+		manager:  "app",
+		contract: APP_TEMPLATES,
+		sample:   app.sampleSpec,
+	}
+}
+
+func (app *AppResourceManager) sampleSpec(operation, version string, _ *config.VersionedResourceConfig) interface{} {
+	var labels = map[string]string{"version": version}
+
+	switch operation {
+	case snapshotOperation:
+		return spec.SnapshotSpec{Name: "contract", Namespace: "contract", Volume: "contract-data",
+			SnapshotClass: app.opts.Policy.SnapshotClass, Labels: labels}
+	case snapshotScheduleOperation:
+		return spec.SnapshotScheduleSpec{Name: "contract", Namespace: "contract", Volume: "contract-data",
+			SnapshotClass: app.opts.Policy.SnapshotClass, BackupExpiration: app.opts.Policy.BackupExpiration,
+			MaxBackupCount: app.opts.Policy.MaxBackupCount, ScheduleType: ztypes.DailySnapshotSchedule,
+			Schedule: helper.CreateSnapshotSchedule(ztypes.DailySnapshotSchedule), Labels: labels}
+	default:
+		return spec.VolumeSpec{Volume: "contract-data", VolumeName: "contract-data", StorageClass: app.opts.Policy.StorageClass,
+			Namespace: "contract", Size: 1, Labels: labels}
+	}
 }
 
 func (app *AppResourceManager) GetAppResources(version string) (*config.VersionedResourceConfig, bool) {
-	resource, ok := app.resourceConfig.Versions[version]
+	resource, ok := app.config().Versions[version]
 	return resource, ok
 }
 
@@ -181,13 +262,14 @@ func (app *AppResourceManager) GetAppResources(version string) (*config.Versione
 
 func (app *AppResourceManager) CreateVolumeAsset(ctx context.Context, volumes ...spec.VolumeSpec) ([]*unstructured.Unstructured, error) {
 
-	appRsc, ok := app.GetAppResources(app.resourceConfig.Version)
+	version := app.config().Version
+	_, ok := app.GetAppResources(version)
 	if !ok {
-		logger.Errorf(ctx, "app resource not available for %s", app.resourceConfig.Version)
+		logger.Errorf(ctx, "app resource not available for %s", version)
 		return nil, errs.ErrIngressResourceFailed
 	}
 
-	fileTemplate := appRsc.GetFileTemplate()
+	fileTemplate := app.fileTemplate(version)
 
 	var objects = make([]*unstructured.Unstructured, 0, len(volumes))
 	for _, volume := range volumes {
@@ -257,15 +339,20 @@ func (app *AppResourceManager) CreateVolumeAsset(ctx context.Context, volumes ..
 
 func (app *AppResourceManager) CreateSnapshotAsset(ctx context.Context, req *object.SnapshotRequest) ([]*unstructured.Unstructured, error) {
 
-	appRsc, ok := app.GetAppResources(req.Version)
+	version := req.Version
+	if len(version) == 0 {
+		version = app.config().Version
+	}
+
+	_, ok := app.GetAppResources(version)
 	if !ok {
-		logger.Errorf(ctx, "app resource not available for %s", req.Version)
+		logger.Errorf(ctx, "app resource not available for %s", version)
 		return nil, errs.ErrIngressResourceFailed
 	}
 
-	fileTemplate := appRsc.GetFileTemplate()
+	fileTemplate := app.fileTemplate(version)
 
-	snapshotClass := vars.AppConfig.Policy.SnapshotClass
+	snapshotClass := app.opts.Policy.SnapshotClass
 
 	var specArr []string
 	var err error
@@ -278,7 +365,7 @@ func (app *AppResourceManager) CreateSnapshotAsset(ctx context.Context, req *obj
 		Labels:        req.Labels,
 	}
 
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"SNAPSHOT"}, snapshotSpec)
+	specArr, err = fileTemplate.ExecuteTemplates(APP_TEMPLATES[snapshotOperation], snapshotSpec)
 
 	if err != nil {
 		logger.Errorf(ctx, "backup templates for version %s failed - %s", req.Labels["version"], err)
@@ -289,17 +376,22 @@ func (app *AppResourceManager) CreateSnapshotAsset(ctx context.Context, req *obj
 }
 
 func (app *AppResourceManager) CreateSnapshotScheduleAsset(ctx context.Context, req *object.SnapshotScheduleRequest) ([]*unstructured.Unstructured, error) {
-	appRsc, ok := app.GetAppResources(req.Version)
+	version := req.Version
+	if len(version) == 0 {
+		version = app.config().Version
+	}
+
+	_, ok := app.GetAppResources(version)
 	if !ok {
-		logger.Errorf(ctx, "app resource not available for %s", req.Version)
+		logger.Errorf(ctx, "app resource not available for %s", version)
 		return nil, errs.ErrIngressResourceFailed
 	}
 
-	fileTemplate := appRsc.GetFileTemplate()
+	fileTemplate := app.fileTemplate(version)
 
-	snapshotClass := vars.AppConfig.Policy.SnapshotClass
-	expiration := vars.AppConfig.Policy.BackupExpiration
-	maxBackupCount := vars.AppConfig.Policy.MaxBackupCount
+	snapshotClass := app.opts.Policy.SnapshotClass
+	expiration := app.opts.Policy.BackupExpiration
+	maxBackupCount := app.opts.Policy.MaxBackupCount
 
 	var specArr []string
 	var err error
@@ -316,7 +408,7 @@ func (app *AppResourceManager) CreateSnapshotScheduleAsset(ctx context.Context,
 		Labels:           req.Labels,
 	}
 
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"SCHEDULE_SNAPSHOT"}, snapshotSpec)
+	specArr, err = fileTemplate.ExecuteTemplates(APP_TEMPLATES[snapshotScheduleOperation], snapshotSpec)
 
 	if err != nil {
 		logger.Errorf(ctx, "backup templates for version %s failed - %s", req.Labels["version"], err)
@@ -28,7 +28,7 @@ func createProjectManager(iTypes ...ztypes.InstanceType) (interfaces.ProjectReso
 			if !ok {
 				return nil, errors.New("unable to create zcash resource manager")
 			}
-			zcashManager, err := NewZcashInstanceResourceManager(zcashCfg)
+			zcashManager, err := NewZcashInstanceResourceManager(zcashCfg, testOptions())
 			if err != nil {
 				return nil, err
 			}
@@ -39,7 +39,7 @@ func createProjectManager(iTypes ...ztypes.InstanceType) (interfaces.ProjectReso
 			if !ok {
 				return nil, errors.New("unable to create lwd resource manager")
 			}
-			lwdManager, err := NewLWDInstanceResourceManager(lwdCfg)
+			lwdManager, err := NewLWDInstanceResourceManager(lwdCfg, testOptions())
 			if err != nil {
 				return nil, err
 			}
@@ -47,7 +47,7 @@ func createProjectManager(iTypes ...ztypes.InstanceType) (interfaces.ProjectReso
 		}
 	}
 
-	projectManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, instanceMap)
+	projectManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, instanceMap, testOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +65,7 @@ func Test_NewIngressResourceManager(t *testing.T) {
 	projectManager, err := createProjectManager()
 	assert.NoErrorf(t, err, "Unable to create project resource manager")
 
-	ingressManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager)
+	ingressManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager, testOptions())
 	assert.NoErrorf(t, err, "Failed to create ingress manager")
 	assert.NotNilf(t, ingressManager, "Failed to created ingress manager")
 }
@@ -79,7 +79,7 @@ func Test_GetIngressResources(t *testing.T) {
 	projectManager, err := createProjectManager()
 	assert.NoError(t, err)
 
-	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager)
+	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager, testOptions())
 	assert.NoError(t, err)
 	assert.NotNil(t, appManager)
 
@@ -97,7 +97,7 @@ func Test_CreateProjectIngressAsset(t *testing.T) {
 	projectManager, err := createProjectManager(ztypes.InstanceTypeZCASH)
 	assert.NoError(t, err)
 
-	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager)
+	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager, testOptions())
 	assert.NoError(t, err)
 	assert.NotNil(t, appManager)
 
@@ -130,7 +130,7 @@ func Test_CreateControllerIngressAsset(t *testing.T) {
 	projectManager, err := createProjectManager()
 	assert.NoErrorf(t, err, "Unable to create project resource manager")
 
-	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager)
+	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, projectManager, testOptions())
 	assert.NoError(t, err)
 	assert.NotNil(t, appManager)
 
@@ -152,7 +152,7 @@ func Test_CreateSnapshotAsset(t *testing.T) {
 	ctx := context.Background()
 	test.InitTest(ctx)
 
-	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, nil)
+	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, nil, testOptions())
 	assert.NoErrorf(t, err, "Failed to create ingress manager")
 	assert.NotNilf(t, appManager, "Failed to create ingress manager")
 
@@ -171,7 +171,7 @@ func Test_CreateSnapshotScheduleAsset(t *testing.T) {
 	ctx := context.Background()
 	test.InitTest(ctx)
 
-	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, nil)
+	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, nil, testOptions())
 	assert.NoError(t, err)
 	assert.NotNil(t, appManager)
 
@@ -192,7 +192,7 @@ func Test_CreateVolumeAsset(t *testing.T) {
 	ctx := context.Background()
 	test.InitTest(ctx)
 
-	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, nil)
+	appManager, err := NewAppResourceManager(vars.ResourceConfig.App, nil, testOptions())
 	assert.NoError(t, err)
 	assert.NotNil(t, appManager)
 
@@ -0,0 +1,71 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/config"
+)
+
+const (
+	// AUTHZ_SERVICE_NAME and AUTHZ_SERVICE_PORT locate the authorization server the project templates
+	// deploy in each project namespace. Envoy is pointed at it when no AuthServerURL is configured.
+	AUTHZ_SERVICE_NAME = "authz-service"
+	AUTHZ_SERVICE_PORT = 50051
+
+	DEFAULT_AUTHZ_DATABASE_SECRET  = "authz-database"
+	DEFAULT_AUTHZ_DATABASE_URL_KEY = "url"
+
+	authzImage = "authz"
+)
+
+// AuthzOptions configures the authorization server deployed with each project when access
+// authorization is enabled.
+type AuthzOptions struct {
+	// DatabaseSecret names the Secret, in the project namespace, holding the database connection URL
+	// under DatabaseURLKey. The URL is never rendered into the templates.
+	DatabaseSecret string
+	DatabaseURLKey string
+}
+
+// AuthzSpec is what the AUTHZ_* project templates are rendered with.
+type AuthzSpec struct {
+	Image          string
+	Port           int32
+	DatabaseSecret string
+	DatabaseURLKey string
+}
+
+func (o Options) authzSpec(ctx context.Context, projResources *config.VersionedResourceConfig) (AuthzSpec, error) {
+	var authz = AuthzSpec{
+		Port:           AUTHZ_SERVICE_PORT,
+		DatabaseSecret: o.Authz.DatabaseSecret,
+		DatabaseURLKey: o.Authz.DatabaseURLKey,
+	}
+
+	image := projResources.GetImage(authzImage)
+	if image == nil {
+		logger.Errorf(ctx, "Project version %s does not define the %s image", projResources.Version, authzImage)
+		return authz, errs.ErrProjectResourceFailed
+	}
+	authz.Image = imageURL(image)
+	if image.Port > 0 {
+		authz.Port = image.Port
+	}
+
+	if len(authz.DatabaseSecret) == 0 {
+		authz.DatabaseSecret = DEFAULT_AUTHZ_DATABASE_SECRET
+	}
+	if len(authz.DatabaseURLKey) == 0 {
+		authz.DatabaseURLKey = DEFAULT_AUTHZ_DATABASE_URL_KEY
+	}
+
+	return authz, nil
+}
+
+// authzServiceHost returns the cluster DNS name of the authorization server of a project namespace.
+func authzServiceHost(namespace string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", AUTHZ_SERVICE_NAME, namespace)
+}
@@ -0,0 +1,26 @@
+package rsc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_EnvoySpecAuthz(t *testing.T) {
+	var opts Options
+
+	envoy := opts.envoySpec("project1", 8080)
+	assert.False(t, envoy.AccessAuthorization)
+	assert.Empty(t, envoy.AuthServerURL)
+
+	opts.Features.AccessAuthorizationEnabled = true
+	envoy = opts.envoySpec("project1", 8080)
+	assert.True(t, envoy.AccessAuthorization)
+	assert.Equal(t, "authz-service.project1.svc.cluster.local", envoy.AuthServerURL)
+	assert.EqualValues(t, AUTHZ_SERVICE_PORT, envoy.AuthServerPort)
+
+	opts.Envoy.AuthServerURL = "authz.zbi.svc.cluster.local"
+	opts.Envoy.AuthServerPort = 9000
+	envoy = opts.envoySpec("project1", 8080)
+	assert.Equal(t, "authz.zbi.svc.cluster.local", envoy.AuthServerURL)
+	assert.EqualValues(t, 9000, envoy.AuthServerPort)
+}
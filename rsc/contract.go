@@ -0,0 +1,164 @@
+package rsc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/internal/helper"
+)
+
+const (
+	deploymentOperation       = "CreateDeploymentResourceAssets"
+	startOperation            = "CreateStartResourceAssets"
+	ingressOperation          = "CreateIngressAsset"
+	rotationOperation         = "CreateRotationAssets"
+	credentialExpiryOperation = "CreateCredentialExpiryAssets"
+	projectOperation          = "CreateProjectAssets"
+	authzOperation            = "CreateProjectAssets (access authorization)"
+	projectIngressOperation   = "CreateProjectIngressAsset"
+	volumeOperation           = "CreateVolumeAsset"
+	snapshotOperation         = "CreateSnapshotAsset"
+	snapshotScheduleOperation = "CreateSnapshotScheduleAsset"
+)
+
+// TemplateContract maps each Create* operation of a manager to the template keys it executes.
+type TemplateContract map[string][]string
+
+// TemplateVerifier is implemented by managers that can check every loaded version of their
+// templates against the keys their operations execute.
+type TemplateVerifier interface {
+	VerifyTemplates(ctx context.Context) TemplateReport
+}
+
+type TemplateError struct {
+	Manager   string
+	Version   string
+	Operation string
+	Key       string
+	Missing   bool
+	Err       error
+}
+
+func (e TemplateError) String() string {
+	if e.Missing {
+		return fmt.Sprintf("%s %s: %s requires missing template %s", e.Manager, e.Version, e.Operation, e.Key)
+	}
+	return fmt.Sprintf("%s %s: %s template %s is broken - %s", e.Manager, e.Version, e.Operation, e.Key, e.Err)
+}
+
+// TemplateReport lists every missing or broken template key found during verification.
+type TemplateReport []TemplateError
+
+func (r TemplateReport) Error() string {
+	var lines = make([]string, len(r))
+	for index, e := range r {
+		lines[index] = e.String()
+	}
+	return fmt.Sprintf("template verification failed:\n  %s", strings.Join(lines, "\n  "))
+}
+
+// Err returns the report as an error, or nil when nothing was found.
+func (r TemplateReport) Err() error {
+	if len(r) == 0 {
+		return nil
+	}
+	return r
+}
+
+// templateVerification describes how to dry-render the templates of one manager.
+type templateVerification struct {
+	manager   string
+	contract  TemplateContract
+	fragments map[string]bool
+	sample    func(operation, version string, cfg *config.VersionedResourceConfig) interface{}
+}
+
+// verify executes each key of the contract for every version against a representative spec and checks
+// that the output decodes. Keys listed in fragments render JSON snippets rather than Kubernetes objects.
+func (v templateVerification) verify(versions map[string]*config.VersionedResourceConfig, templates map[string]*FileTemplate) TemplateReport {
+	var report TemplateReport
+
+	var versionNames = make([]string, 0, len(versions))
+	for version := range versions {
+		versionNames = append(versionNames, version)
+	}
+	sort.Strings(versionNames)
+
+	var operations = make([]string, 0, len(v.contract))
+	for operation := range v.contract {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	for _, version := range versionNames {
+		fileTemplate := templates[version]
+
+		for _, operation := range operations {
+			sample := v.sample(operation, version, versions[version])
+			for _, key := range v.contract[operation] {
+				data, err := fileTemplate.ExecuteTemplate(key, sample)
+				if err == nil {
+					if v.fragments[key] {
+						var fragment interface{}
+						err = json.Unmarshal([]byte(data), &fragment)
+					} else {
+						_, err = helper.CreateYAMLObject(data)
+					}
+				}
+
+				if err != nil {
+					report = append(report, TemplateError{Manager: v.manager, Version: version, Operation: operation,
+						Key: key, Missing: strings.Contains(err.Error(), "no template"), Err: err})
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// sampleInstance returns the instance used to dry-render instance templates.
+func sampleInstance(iType ztypes.InstanceType, version string) entity.Instance {
+	return entity.Instance{
+		Project:        "contract",
+		Name:           "contract",
+		Version:        version,
+		Network:        ztypes.NetworkTypeTest,
+		Owner:          "contract",
+		Status:         "New",
+		DataSourceType: ztypes.NoDataSource,
+		InstanceType:   iType,
+	}
+}
+
+// sampleProject returns the project used to dry-render project templates.
+func sampleProject(version string) *entity.Project {
+	return &entity.Project{
+		Name:    "contract",
+		Version: version,
+		Network: ztypes.NetworkTypeTest,
+		Owner:   "contract",
+		TeamId:  "contract",
+		Status:  "New",
+	}
+}
+
+func imageURL(image *config.ImageConfig) string {
+	if image == nil {
+		return ""
+	}
+	return image.URL
+}
+
+func imageVersion(image *config.ImageConfig) string {
+	if image == nil {
+		return ""
+	}
+	return image.Version
+}
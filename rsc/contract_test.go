@@ -0,0 +1,57 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/fake/test"
+	"testing"
+)
+
+func Test_TemplateReport(t *testing.T) {
+	var report TemplateReport
+	assert.NoError(t, report.Err())
+
+	report = append(report, TemplateError{Manager: "zcash", Version: "v1", Operation: deploymentOperation, Key: "ZCASH_CONF", Missing: true})
+	assert.Error(t, report.Err())
+	assert.Contains(t, report.Error(), "v1: CreateDeploymentResourceAssets requires missing template ZCASH_CONF")
+}
+
+func Test_VerifyTemplates(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zcashConfig, ok := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	assert.True(t, ok)
+
+	zcashResource, err := NewZcashInstanceResourceManager(zcashConfig, testOptions())
+	assert.NoError(t, err)
+
+	report := zcashResource.(TemplateVerifier).VerifyTemplates(ctx)
+	assert.Emptyf(t, report, "unexpected template errors - %s", report)
+}
+
+func Test_VerifyMissingTemplate(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zcashConfig, ok := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	assert.True(t, ok)
+
+	zcashResource, err := NewZcashInstanceResourceManager(zcashConfig, testOptions())
+	assert.NoError(t, err)
+
+	zcashManager := zcashResource.(*ZcashInstanceResourceManager)
+	verification := zcashManager.verification()
+	verification.contract = TemplateContract{deploymentOperation: {"UNDEFINED_TEMPLATE"}}
+
+	report := verification.verify(map[string]*config.VersionedResourceConfig{"v1": zcashConfig.Versions["v1"]}, zcashManager.templates)
+	assert.Len(t, report, 1)
+	assert.True(t, report[0].Missing)
+	assert.Equal(t, "UNDEFINED_TEMPLATE", report[0].Key)
+	assert.Equal(t, deploymentOperation, report[0].Operation)
+}
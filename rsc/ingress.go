@@ -0,0 +1,54 @@
+package rsc
+
+import (
+	"context"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/utils"
+	"github.com/zbitech/mgr/internal/helper"
+	"github.com/zbitech/mgr/internal/ingress"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// reconcileIngress applies the include or route rendered in data to proxy. When there is no proxy yet,
+// it starts from the one rendered by bootstrap.
+func reconcileIngress(ctx context.Context, proxy *unstructured.Unstructured, kind ingress.Kind, data string,
+	action ztypes.EventAction, bootstrap func() (string, error)) (*unstructured.Unstructured, error) {
+
+	if proxy == nil {
+		proxyData, err := bootstrap()
+		if err != nil {
+			logger.Errorf(ctx, "Ingress template failed - %s", err)
+			return nil, errs.ErrIngressResourceFailed
+		}
+
+		proxy = new(unstructured.Unstructured)
+		if err = helper.DecodeJSON(proxyData, proxy); err != nil {
+			logger.Errorf(ctx, "Ingress template failed - %s", err)
+			return nil, errs.ErrIngressResourceFailed
+		}
+	}
+
+	entry, err := ingress.DecodeEntry(data)
+	if err != nil {
+		logger.Errorf(ctx, "Ingress entry template failed - %s", err)
+		return nil, errs.ErrIngressResourceFailed
+	}
+
+	reconciler, err := ingress.Load(proxy)
+	if err != nil {
+		logger.Errorf(ctx, "Ingress %s cannot be reconciled - %s", proxy.GetName(), err)
+		return nil, errs.ErrIngressResourceFailed
+	}
+
+	if err = reconciler.Apply(kind, entry, ingress.OperationFor(action)); err != nil {
+		logger.Errorf(ctx, "Ingress %s cannot be reconciled - %s", proxy.GetName(), err)
+		return nil, errs.ErrIngressResourceFailed
+	}
+
+	proxy = reconciler.Object()
+	logger.Debugf(ctx, "Reconciled ingress %s - %s", proxy.GetName(), utils.MarshalObject(proxy))
+	return proxy, nil
+}
@@ -0,0 +1,41 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/internal/ingress"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func Test_ReconcileIngress(t *testing.T) {
+	ctx := context.Background()
+
+	bootstrap := func() (string, error) {
+		return `{"apiVersion": "projectcontour.io/v1", "kind": "HTTPProxy", "metadata": {"name": "project-proxy", "namespace": "sample"}, "spec": {"routes": []}}`, nil
+	}
+	route := `{"conditions": [{"prefix": "/node/v1"}], "services": [{"name": "zcashd-svc-node", "port": 8080}]}`
+	stopped := `{"conditions": [{"prefix": "/node/v1"}], "directResponsePolicy": {"statusCode": 503}}`
+
+	proxy, err := reconcileIngress(ctx, nil, ingress.Route, route, ztypes.EventActionCreate, bootstrap)
+	assert.NoError(t, err)
+	assert.Equal(t, "project-proxy", proxy.GetName())
+
+	proxy, err = reconcileIngress(ctx, proxy, ingress.Route, stopped, ztypes.EventActionStopInstance, bootstrap)
+	assert.NoError(t, err)
+	routes, _, _ := unstructured.NestedSlice(proxy.Object, "spec", "routes")
+	assert.Len(t, routes, 1)
+	assert.Contains(t, routes[0], "directResponsePolicy")
+
+	proxy, err = reconcileIngress(ctx, proxy, ingress.Route, route, ztypes.EventActionDelete, bootstrap)
+	assert.NoError(t, err)
+	routes, _, _ = unstructured.NestedSlice(proxy.Object, "spec", "routes")
+	assert.Empty(t, routes)
+
+	_, err = reconcileIngress(ctx, nil, ingress.Route, route, ztypes.EventActionCreate, func() (string, error) {
+		return "", fmt.Errorf("no template")
+	})
+	assert.Error(t, err)
+}
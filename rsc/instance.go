@@ -0,0 +1,89 @@
+package rsc
+
+import (
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+)
+
+// InstanceDetails are the settings recorded on every instance created by the managers of this module
+// beyond those of entity.Instance.
+type InstanceDetails struct {
+	Metadata `bson:",inline"`
+
+	// Profile names the resource profile the containers of the instance are sized with.
+	Profile string `json:"profile,omitempty" bson:"profile,omitempty"`
+
+	// VolumeSources names the snapshot each volume of a restored instance is created from, keyed by
+	// volume name.
+	VolumeSources map[string]string `json:"volumeSources,omitempty" bson:"volumeSources,omitempty"`
+}
+
+func (d *InstanceDetails) instanceDetails() *InstanceDetails {
+	return d
+}
+
+// clone returns the details with maps of their own.
+func (d InstanceDetails) clone() InstanceDetails {
+	d.Labels = mergeMetadata(d.Labels, nil)
+	d.Annotations = mergeMetadata(d.Annotations, nil)
+	d.VolumeSources = mergeMetadata(d.VolumeSources, nil)
+	return d
+}
+
+// InstanceRequestDetails are the settings every instance request accepts beyond those of
+// object.InstanceRequest.
+type InstanceRequestDetails struct {
+	Metadata
+
+	// Profile names the resource profile of the instance. The default profile is used when it is empty.
+	Profile string `json:"profile,omitempty"`
+
+	// VolumeSizes sizes the volumes of the instance, in GiB, keyed by volume name.
+	VolumeSizes map[string]int64 `json:"volumeSizes,omitempty"`
+}
+
+func (d InstanceRequestDetails) requestDetails() InstanceRequestDetails {
+	return d
+}
+
+// detailsOf returns the details recorded on instance, or nil when it was not created by the managers of
+// this module.
+func detailsOf(instance entity.InstanceIF) *InstanceDetails {
+	if detailed, ok := instance.(interface{ instanceDetails() *InstanceDetails }); ok {
+		return detailed.instanceDetails()
+	}
+	return nil
+}
+
+// requestDetailsOf returns the details of request, which are empty when it was not created by the
+// managers of this module.
+func requestDetailsOf(request object.InstanceRequestIF) InstanceRequestDetails {
+	if detailed, ok := request.(interface{ requestDetails() InstanceRequestDetails }); ok {
+		return detailed.requestDetails()
+	}
+	return InstanceRequestDetails{}
+}
+
+// asZcashInstance returns instance as a ZcashInstance. Zcash instances created elsewhere are copied
+// with empty details, so changes to the copy are not seen by the caller.
+func asZcashInstance(instance entity.InstanceIF) (*ZcashInstance, bool) {
+	switch zcash := instance.(type) {
+	case *ZcashInstance:
+		return zcash, true
+	case *entity.ZcashInstance:
+		return &ZcashInstance{ZcashInstance: *zcash}, true
+	}
+	return nil, false
+}
+
+// asLWDInstance returns instance as an LWDInstance. Lightwalletd instances created elsewhere are copied
+// with empty details, so changes to the copy are not seen by the caller.
+func asLWDInstance(instance entity.InstanceIF) (*LWDInstance, bool) {
+	switch lwd := instance.(type) {
+	case *LWDInstance:
+		return lwd, true
+	case *entity.LWDInstance:
+		return &LWDInstance{LWDInstance: *lwd}, true
+	}
+	return nil, false
+}
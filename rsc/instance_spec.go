@@ -0,0 +1,48 @@
+package rsc
+
+import (
+	"github.com/zbitech/common/pkg/model/spec"
+)
+
+// NetworkPolicySpec names the namespaces, besides the project itself, that may reach instance pods.
+type NetworkPolicySpec struct {
+	IngressNamespace    string
+	MonitoringNamespace string
+}
+
+// zcashInstanceSpec adds the settings the zcash templates need beyond spec.ZcashNodeInstanceSpec.
+type zcashInstanceSpec struct {
+	spec.ZcashNodeInstanceSpec
+	NetworkPolicy NetworkPolicySpec
+	Resources     ResourceProfile
+	Probe         ProbeSpec
+
+	// PeerPort is opened to the other zcash nodes of the project when it is set.
+	PeerPort int32
+
+	// Methods are the only JSON-RPC methods the envoy proxy forwards when it is set.
+	Methods []string
+
+	// BlockInterval runs a block generator that mines a block every BlockInterval seconds when it is set.
+	BlockInterval int32
+
+	// InitImage runs the init containers that prepare the zcash.conf of the node and add its credentials
+	// to the envoy configuration.
+	InitImage string
+}
+
+// lwdInstanceSpec adds the settings the lightwalletd templates need beyond spec.LWDInstanceSpec.
+type lwdInstanceSpec struct {
+	spec.LWDInstanceSpec
+	NetworkPolicy NetworkPolicySpec
+	Resources     ResourceProfile
+	Probe         ProbeSpec
+
+	// InitImage runs the init container that adds the credentials of the zcash node to the zcash.conf of
+	// the server.
+	InitImage string
+
+	// CredentialsRotated records when the credentials of the zcash node were rotated, so that the rotation
+	// changes the zcash.conf ConfigMap and restarts the server.
+	CredentialsRotated string
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"github.com/zbitech/common/pkg/utils"
 	"github.com/zbitech/mgr/internal/helper"
+	"github.com/zbitech/mgr/internal/ingress"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sync"
 	"time"
 
 	"github.com/zbitech/common/interfaces"
@@ -17,28 +19,163 @@ import (
 	"github.com/zbitech/common/pkg/model/object"
 	"github.com/zbitech/common/pkg/model/spec"
 	"github.com/zbitech/common/pkg/model/ztypes"
-	"github.com/zbitech/common/pkg/vars"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+var (
+	LWD_TEMPLATES = TemplateContract{
+		deploymentOperation: {"LWD_CONF", "ZCASH_CONF", "ENVOY_CONF", "DEPLOYMENT", "SERVICE", "GRPC_INGRESS", "NETWORK_POLICY", "BACKEND_NETWORK_POLICY"},
+		startOperation:      {"DEPLOYMENT", "SERVICE"},
+		ingressOperation:    {"INGRESS", "INGRESS_STOPPED", "PROJECT_INGRESS"},
+		rotationOperation:   {"ZCASH_CONF"},
+	}
+)
+
+// LWDInstance is a lightwalletd server with the details recorded by this module.
+type LWDInstance struct {
+	entity.LWDInstance `bson:",inline"`
+	InstanceDetails    `bson:",inline"`
+}
+
+// LWDInstanceRequest adds the settings of LWDInstance to object.LWDInstanceRequest.
+type LWDInstanceRequest struct {
+	object.LWDInstanceRequest
+	InstanceRequestDetails
+}
+
 type LWDInstanceResourceManager struct {
+	mu        sync.RWMutex
 	lwdConfig *config.InstanceResourceConfig
+	templates map[string]*FileTemplate
+	rscConfig *config.ResourceConfig
+	opts      Options
 }
 
-func NewLWDInstanceResourceManager(lwdConfig *config.InstanceResourceConfig) (interfaces.InstanceResourceManagerIF, error) {
-	var mgr = &LWDInstanceResourceManager{lwdConfig: lwdConfig}
+func NewLWDInstanceResourceManager(lwdConfig *config.InstanceResourceConfig, opts Options) (interfaces.InstanceResourceManagerIF, error) {
+	lwdConfig, templates, err := loadInstanceConfig(opts.Assets, lwdConfig, object.NO_FUNCS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LWDInstanceResourceManager{lwdConfig: lwdConfig, templates: templates, rscConfig: opts.ResourceConfig, opts: opts}, nil
+}
+
+func (lwd *LWDInstanceResourceManager) config() *config.InstanceResourceConfig {
+	lwd.mu.RLock()
+	defer lwd.mu.RUnlock()
+	return lwd.lwdConfig
+}
+
+func (lwd *LWDInstanceResourceManager) fileTemplate(version string) *FileTemplate {
+	lwd.mu.RLock()
+	defer lwd.mu.RUnlock()
+	return lwd.templates[version]
+}
+
+func (lwd *LWDInstanceResourceManager) PrepareReload(ctx context.Context, cfg *config.InstanceResourceConfig) (func(), error) {
+	lwdConfig, templates, err := loadInstanceConfig(lwd.opts.Assets, cfg, object.NO_FUNCS)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lwd.verification().verify(lwdConfig.Versions, templates).Err(); err != nil {
+		return nil, err
+	}
 
-	for _, cfg := range mgr.lwdConfig.Versions {
-		if err := cfg.Init(vars.ASSET_PATH_DIRECTORY, object.NO_FUNCS); err != nil {
-			return nil, err
+	return func() {
+		lwd.mu.Lock()
+		defer lwd.mu.Unlock()
+		lwd.lwdConfig = lwdConfig
+		lwd.templates = templates
+	}, nil
+}
+
+// PrepareResourceConfigReload swaps in the resource config the zcash service port is read from.
+func (lwd *LWDInstanceResourceManager) PrepareResourceConfigReload(ctx context.Context, rscConfig *config.ResourceConfig) (func(), error) {
+	return func() {
+		lwd.mu.Lock()
+		defer lwd.mu.Unlock()
+		lwd.rscConfig = rscConfig
+	}, nil
+}
+
+// zcashPort returns the service port of the zcash nodes lightwalletd servers connect to.
+func (lwd *LWDInstanceResourceManager) zcashPort() int32 {
+	lwd.mu.RLock()
+	defer lwd.mu.RUnlock()
+
+	if lwd.rscConfig != nil {
+		if zcashRsc, ok := lwd.rscConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH); ok {
+			return zcashRsc.Ports["service"]
 		}
 	}
+	return lwd.lwdConfig.Ports["service"]
+}
+
+func (lwd *LWDInstanceResourceManager) VerifyTemplates(ctx context.Context) TemplateReport {
+	lwd.mu.RLock()
+	versions, templates := lwd.lwdConfig.Versions, lwd.templates
+	lwd.mu.RUnlock()
+
+	return lwd.verification().verify(versions, templates)
+}
+
+func (lwd *LWDInstanceResourceManager) verification() templateVerification {
+	return templateVerification{
+		manager:   string(ztypes.InstanceTypeLWD),
+		contract:  LWD_TEMPLATES,
+		fragments: map[string]bool{"INGRESS": true, "INGRESS_STOPPED": true, "PROJECT_INGRESS": true},
+		sample:    lwd.sampleSpec,
+	}
+}
+
+func (lwd *LWDInstanceResourceManager) sampleSpec(_, version string, instResource *config.VersionedResourceConfig) interface{} {
+	instance := &entity.LWDInstance{Instance: sampleInstance(ztypes.InstanceTypeLWD, version)}
+	instance.ZcashInstance = "contract-zcash"
+
+	zcashPort := lwd.zcashPort()
+
+	zcashInstance := fmt.Sprintf("zcashd-svc-%s.%s.svc.cluster.local", instance.ZcashInstance, instance.GetNamespace())
+
+	// Versions without a probe image are reported when their instances are deployed.
+	probe, _ := lwd.opts.probeSpec(context.Background(), instResource, zcashInstance, zcashPort)
+
+	return lwdInstanceSpec{LWDInstanceSpec: spec.LWDInstanceSpec{
+		InstanceSpec: spec.InstanceSpec{
+			Name:               instance.Name,
+			Project:            instance.Project,
+			Version:            version,
+			ServiceAccountName: helper.CreateServiceAccountName(instance.GetProject()),
+			Namespace:          instance.GetNamespace(),
+			Labels:             helper.CreateInstanceLabels(instance),
+			DomainName:         lwd.opts.Policy.Domain,
+			DomainSecret:       lwd.opts.Policy.CertName,
+			DataSourceType:     instance.DataSourceType},
+		ZcashInstanceName: instance.ZcashInstance,
+		ZcashInstanceUrl:  zcashInstance,
+		ZcashPort:         zcashPort,
+		LightwalletImage:  imageURL(instResource.GetImage("lwd")),
+		Port:              lwd.config().Ports["service"],
+		HttpPort:          lwd.config().Ports["http"],
+		LogLevel:          10,
+		DataVolume:        "lwd-data",
+		Envoy:             lwd.opts.envoySpec(instance.GetNamespace(), lwd.config().Ports["envoy"]),
+	}, NetworkPolicy: lwd.opts.networkPolicySpec(), Resources: lwd.opts.Profiles.Profiles[ztypes.InstanceTypeLWD][lwd.opts.Profiles.Default], Probe: probe,
+		InitImage: imageURL(instResource.GetImage("init"))}
+}
 
-	return mgr, nil
+// volumePolicy returns the volume policy of instances of version.
+func (lwd *LWDInstanceResourceManager) volumePolicy(version string) VolumePolicy {
+	return lwd.opts.volumePolicy(lwd.fileTemplate(version))
+}
+
+// profilePolicy returns the profile policy of instances of version.
+func (lwd *LWDInstanceResourceManager) profilePolicy(version string) ProfilePolicy {
+	return lwd.opts.profilePolicy(ztypes.InstanceTypeLWD, lwd.fileTemplate(version))
 }
 
 func (lwd *LWDInstanceResourceManager) GetInstanceResources(version string) (*config.VersionedResourceConfig, bool) {
-	resource, ok := lwd.lwdConfig.Versions[version]
+	resource, ok := lwd.config().Versions[version]
 	return resource, ok
 }
 
@@ -48,7 +185,7 @@ func (lwd *LWDInstanceResourceManager) CreateInstanceRequest(ctx context.Context
 		return nil, errs.ErrMarshalFailed
 	}
 
-	var lwdReq object.LWDInstanceRequest
+	var lwdReq LWDInstanceRequest
 	if err := json.Unmarshal(jsonStr, &lwdReq); err != nil {
 		logger.Errorf(ctx, "Failed to unmarshal request - %s", err)
 		return nil, errs.ErrMarshalFailed
@@ -65,10 +202,19 @@ func (lwd *LWDInstanceResourceManager) CreateInstance(ctx context.Context, proje
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	lwdRequest := request.(object.LWDInstanceRequest)
+	lwdRequest := request.(LWDInstanceRequest)
+
+	var errors ValidationErrors
+	sizes := lwd.volumePolicy(lwdRequest.GetVersion()).volumeSizes(&errors, instResource, lwdRequest.VolumeSizes)
+	profile := lwd.profilePolicy(lwdRequest.GetVersion()).profileName(&errors, ztypes.InstanceTypeLWD, lwdRequest.Profile)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Lightwalletd instance request %s is invalid - %s", lwdRequest.GetName(), err)
+		return nil, err
+	}
+
 	dataVolume := instResource.Volumes[0]
 
-	lwdInstance := entity.LWDInstance{
+	lwdInstance := LWDInstance{LWDInstance: entity.LWDInstance{
 		Instance: entity.Instance{
 			Project:        project.Name,
 			Name:           request.GetName(),
@@ -77,84 +223,135 @@ func (lwd *LWDInstanceResourceManager) CreateInstance(ctx context.Context, proje
 			Description:    lwdRequest.Description,
 			Owner:          project.GetOwner(),
 			Status:         "New",
-			Timestamp:      time.Now(),
+			Timestamp:      lwd.opts.now(),
 			DataSourceType: request.GetDataSourceType(),
 			DataSource:     request.GetDataSource(),
 			InstanceType:   request.GetInstanceType(),
 			Action:         "created",
-			ActionTime:     time.Now(),
+			ActionTime:     lwd.opts.now(),
 		},
 		LWDDetails: entity.LWDDetails{
 			ZcashInstance: lwdRequest.ZcashInstance,
-			DataVolume:    entity.DataVolume{Name: dataVolume + "-" + lwdRequest.Name, Size: 10, Volume: dataVolume},
+			DataVolume:    entity.DataVolume{Name: dataVolume + "-" + lwdRequest.Name, Size: sizes[dataVolume], Volume: dataVolume},
 		},
-	}
+	}, InstanceDetails: InstanceDetails{Profile: profile}}
 
 	return &lwdInstance, nil
 }
 
 func (lwd *LWDInstanceResourceManager) UpdateInstance(ctx context.Context, project *entity.Project, instance entity.InstanceIF, request object.InstanceRequestIF) error {
 
-	lwdRequest := request.(object.LWDInstanceRequest)
-	lwdInstance := instance.(*entity.LWDInstance)
+	lwdRequest := request.(LWDInstanceRequest)
+	lwdInstance, ok := instance.(*LWDInstance)
+	if !ok {
+		logger.Errorf(ctx, "Lightwalletd instance %s was not created by this manager", instance.GetName())
+		return errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
+	if !ok {
+		logger.Errorf(ctx, "Lightwalletd resource not available for %s", lwdInstance.Version)
+		return errs.ErrInstanceResourceFailed
+	}
+
+	var errors ValidationErrors
+	if len(lwdRequest.Profile) > 0 {
+		lwd.profilePolicy(lwdInstance.Version).profileName(&errors, ztypes.InstanceTypeLWD, lwdRequest.Profile)
+	}
+	lwd.volumePolicy(lwdInstance.Version).resizeVolumes(&errors, instResource, lwdRequest.VolumeSizes, &lwdInstance.DataVolume)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Lightwalletd instance request %s is invalid - %s", lwdRequest.GetName(), err)
+		return err
+	}
+
+	if len(lwdRequest.Profile) > 0 {
+		lwdInstance.Profile = lwdRequest.Profile
+	}
 
 	lwdInstance.Action = "updated"
-	lwdInstance.ActionTime = time.Now()
+	lwdInstance.ActionTime = lwd.opts.now()
 	lwdInstance.Description = lwdRequest.Description
 	lwdInstance.ZcashInstance = lwdRequest.ZcashInstance
 
 	return nil
 }
 
-func (lwd *LWDInstanceResourceManager) CreateDeploymentResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
-	lwdInstance := instance.(*entity.LWDInstance)
-	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
-	if !ok {
-		logger.Errorf(ctx, "Lightwallet resource not available for %s", lwdInstance.Version)
-		return nil, errs.ErrInstanceResourceFailed
-	}
-
+// instanceSpec returns the spec the deployment, start and rotation templates of lwdInstance are rendered with.
+func (lwd *LWDInstanceResourceManager) instanceSpec(ctx context.Context, lwdInstance *LWDInstance, instResource *config.VersionedResourceConfig) (lwdInstanceSpec, error) {
 	lwdImage := instResource.GetImage("lwd")
 	if lwdImage == nil {
-		return nil, errs.ErrInstanceResourceFailed
+		return lwdInstanceSpec{}, errs.ErrInstanceResourceFailed
+	}
+
+	initImage := instResource.GetImage("init")
+	if initImage == nil {
+		logger.Errorf(ctx, "Instance version %s does not define the init image", instResource.Version)
+		return lwdInstanceSpec{}, errs.ErrInstanceResourceFailed
 	}
 
 	zcashInstance := fmt.Sprintf("zcashd-svc-%s.%s.svc.cluster.local", lwdInstance.ZcashInstance, lwdInstance.GetNamespace())
 
-	zcashPort := lwd.lwdConfig.Ports["service"]
-	zcashRsc, zcashOk := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	if zcashOk {
-		zcashPort = zcashRsc.Ports["service"]
-	}
+	zcashPort := lwd.zcashPort()
 
 	lwdSpec := spec.LWDInstanceSpec{
 		InstanceSpec: spec.InstanceSpec{
 			Name:               lwdInstance.Name,
 			Project:            lwdInstance.Project,
 			Version:            lwdInstance.Version,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
+			ServiceAccountName: helper.CreateServiceAccountName(lwdInstance.GetProject()),
 			Namespace:          lwdInstance.GetNamespace(),
 			Labels:             helper.CreateInstanceLabels(lwdInstance),
-			DomainName:         vars.AppConfig.Policy.Domain,
-			DomainSecret:       vars.AppConfig.Policy.CertName,
+			DomainName:         lwd.opts.Policy.Domain,
+			DomainSecret:       lwd.opts.Policy.CertName,
 			DataSourceType:     lwdInstance.DataSourceType,
 			DataSource:         lwdInstance.DataSource},
 		ZcashInstanceName: lwdInstance.ZcashInstance,
 		ZcashInstanceUrl:  zcashInstance,
 		ZcashPort:         zcashPort,
 		LightwalletImage:  lwdImage.URL,
-		Port:              lwd.lwdConfig.Ports["service"],
-		HttpPort:          lwd.lwdConfig.Ports["http"],
+		Port:              lwd.config().Ports["service"],
+		HttpPort:          lwd.config().Ports["http"],
 		LogLevel:          10,
 		DataVolume:        lwdInstance.DataVolume.Name,
-		Envoy:             helper.CreateEnvoySpec(lwd.lwdConfig.Ports["envoy"]),
+		Envoy:             lwd.opts.envoySpec(lwdInstance.GetNamespace(), lwd.config().Ports["envoy"]),
+	}
+
+	resources, err := lwd.profilePolicy(lwdInstance.Version).profile(ctx, ztypes.InstanceTypeLWD, lwdInstance.Profile)
+	if err != nil {
+		return lwdInstanceSpec{}, err
+	}
+
+	// Servers are ready when the node they read the chain from is.
+	probe, err := lwd.opts.probeSpec(ctx, instResource, zcashInstance, zcashPort)
+	if err != nil {
+		return lwdInstanceSpec{}, err
+	}
+
+	return lwdInstanceSpec{LWDInstanceSpec: lwdSpec, NetworkPolicy: lwd.opts.networkPolicySpec(), Resources: resources, Probe: probe,
+		InitImage: initImage.URL}, nil
+}
+
+func (lwd *LWDInstanceResourceManager) CreateDeploymentResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+	lwdInstance, ok := asLWDInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
+	if !ok {
+		logger.Errorf(ctx, "Lightwallet resource not available for %s", lwdInstance.Version)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	instanceSpec, err := lwd.instanceSpec(ctx, lwdInstance, instResource)
+	if err != nil {
+		return nil, err
 	}
 
 	var specArr []string
-	var err error
 
-	fileTemplate := instResource.GetFileTemplate()
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"LWD_CONF", "ZCASH_CONF", "ENVOY_CONF", "DEPLOYMENT", "SERVICE", "INGRESS"}, lwdSpec)
+	fileTemplate := lwd.fileTemplate(lwdInstance.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(LWD_TEMPLATES[deploymentOperation], instanceSpec)
 	if err != nil {
 		logger.Errorf(ctx, "Lightwalletd templates for version %s failed - %s", lwdInstance.Version, err)
 		return nil, errs.ErrInstanceResourceFailed
@@ -166,17 +363,14 @@ func (lwd *LWDInstanceResourceManager) CreateDeploymentResourceAssets(ctx contex
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	volumeDataSource := lwdInstance.DataSourceType == ztypes.VolumeDataSource
-	snapshotDataSource := lwdInstance.DataSourceType == ztypes.SnapshotDataSource
-	storageClass := vars.AppConfig.Policy.StorageClass
+	storageClass := lwd.opts.Policy.StorageClass
 
 	var volumeSpecs = []spec.VolumeSpec{
-		{Volume: lwdInstance.DataVolume.Volume, VolumeName: lwdInstance.DataVolume.Name, StorageClass: storageClass,
-			Namespace: lwdInstance.GetNamespace(), SourceName: lwdInstance.DataSource, VolumeDataSource: volumeDataSource,
-			SnapshotDataSource: snapshotDataSource, Size: lwdInstance.DataVolume.Size, Labels: lwdSpec.Labels},
+		withVolumeSource(spec.VolumeSpec{Volume: lwdInstance.DataVolume.Volume, VolumeName: lwdInstance.DataVolume.Name, StorageClass: storageClass,
+			Namespace: lwdInstance.GetNamespace(), Size: lwdInstance.DataVolume.Size, Labels: instanceSpec.Labels}, &lwdInstance.Instance, &lwdInstance.InstanceDetails),
 	}
 
-	appRsc := vars.ManagerFactory.GetAppResourceManager(ctx)
+	appRsc := lwd.opts.appManager(ctx)
 	volumes, err := appRsc.CreateVolumeAsset(ctx, volumeSpecs...)
 	if err != nil {
 		logger.Errorf(ctx, "Lightwalletd volume templates for version %s failed - %s", lwdInstance.Version, err)
@@ -184,57 +378,30 @@ func (lwd *LWDInstanceResourceManager) CreateDeploymentResourceAssets(ctx contex
 	}
 
 	objects = append(objects, volumes...)
-	return objects, nil
+	return applyInstanceMetadata(instance, objects), nil
 }
 
 func (lwd *LWDInstanceResourceManager) CreateStartResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
-	lwdInstance := instance.(*entity.LWDInstance)
-	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
+	lwdInstance, ok := asLWDInstance(instance)
 	if !ok {
-		logger.Errorf(ctx, "Lightwalletd resource not available for %s", lwdInstance.Version)
-		return nil, errs.ErrInstanceResourceFailed
+		return nil, errs.ErrInstanceDataFailed
 	}
 
-	lwdImage := instResource.GetImage("lwd")
-	if lwdImage == nil {
+	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
+	if !ok {
+		logger.Errorf(ctx, "Lightwalletd resource not available for %s", lwdInstance.Version)
 		return nil, errs.ErrInstanceResourceFailed
 	}
-	zcashInstance := fmt.Sprintf("zcashd-svc-%s.%s.svc.cluster.local", lwdInstance.ZcashInstance, lwdInstance.GetNamespace())
-
-	zcashPort := lwd.lwdConfig.Ports["service"]
-	zcashRsc, zcashOk := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	if zcashOk {
-		zcashPort = zcashRsc.Ports["service"]
-	}
 
-	lwdSpec := spec.LWDInstanceSpec{
-		InstanceSpec: spec.InstanceSpec{
-			Name:               lwdInstance.Name,
-			Project:            lwdInstance.Project,
-			Version:            lwdInstance.Version,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
-			Namespace:          lwdInstance.GetNamespace(),
-			Labels:             helper.CreateInstanceLabels(lwdInstance),
-			DomainName:         vars.AppConfig.Policy.Domain,
-			DomainSecret:       vars.AppConfig.Policy.CertName,
-			DataSourceType:     lwdInstance.DataSourceType,
-			DataSource:         lwdInstance.DataSource},
-		ZcashInstanceName: lwdInstance.ZcashInstance,
-		ZcashInstanceUrl:  zcashInstance,
-		ZcashPort:         zcashPort,
-		LightwalletImage:  lwdImage.URL,
-		Port:              lwd.lwdConfig.Ports["service"],
-		HttpPort:          lwd.lwdConfig.Ports["http"],
-		LogLevel:          10,
-		DataVolume:        lwdInstance.DataVolume.Name,
-		Envoy:             helper.CreateEnvoySpec(lwd.lwdConfig.Ports["envoy"]),
+	instanceSpec, err := lwd.instanceSpec(ctx, lwdInstance, instResource)
+	if err != nil {
+		return nil, err
 	}
 
 	var specArr []string
-	var err error
 
-	fileTemplate := instResource.GetFileTemplate()
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"DEPLOYMENT", "SERVICE"}, lwdSpec)
+	fileTemplate := lwd.fileTemplate(lwdInstance.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(LWD_TEMPLATES[startOperation], instanceSpec)
 	if err != nil {
 		logger.Errorf(ctx, "Lightwalletd templates for version %s failed - %s", lwdInstance.Version, err)
 		return nil, errs.ErrInstanceResourceFailed
@@ -246,45 +413,45 @@ func (lwd *LWDInstanceResourceManager) CreateStartResourceAssets(ctx context.Con
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	return objects, nil
+	return applyInstanceMetadata(instance, objects), nil
 }
 
 func (lwd *LWDInstanceResourceManager) CreateIngressAsset(ctx context.Context, projIngress *unstructured.Unstructured, instance entity.InstanceIF, action ztypes.EventAction) (*unstructured.Unstructured, error) {
-	lwdInstance := instance.(*entity.LWDInstance)
-	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
+	lwdInstance, ok := asLWDInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	_, ok = lwd.GetInstanceResources(lwdInstance.Version)
 	if !ok {
 		logger.Errorf(ctx, "Lightwalletd resource not available for %s", lwdInstance.Version)
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
 	zcashInstance := fmt.Sprintf("zcashd-svc-%s.%s.svc.cluster.local", lwdInstance.ZcashInstance, lwdInstance.GetNamespace())
-	zcashPort := lwd.lwdConfig.Ports["service"]
-	zcashRsc, zcashOk := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	if zcashOk {
-		zcashPort = zcashRsc.Ports["service"]
-	}
+	zcashPort := lwd.zcashPort()
 
 	lwdSpec := spec.LWDInstanceSpec{
 		InstanceSpec: spec.InstanceSpec{
 			Name:               lwdInstance.Name,
 			Project:            lwdInstance.Project,
 			Version:            lwdInstance.Version,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
+			ServiceAccountName: helper.CreateServiceAccountName(lwdInstance.GetProject()),
 			Namespace:          lwdInstance.GetNamespace(),
 			Labels:             helper.CreateInstanceLabels(lwdInstance),
-			DomainName:         vars.AppConfig.Policy.Domain,
-			DomainSecret:       vars.AppConfig.Policy.CertName},
+			DomainName:         lwd.opts.Policy.Domain,
+			DomainSecret:       lwd.opts.Policy.CertName},
 		ZcashInstanceName: lwdInstance.ZcashInstance,
 		ZcashInstanceUrl:  zcashInstance,
 		ZcashPort:         zcashPort,
-		Envoy:             helper.CreateEnvoySpec(lwd.lwdConfig.Ports["envoy"]),
+		Envoy:             lwd.opts.envoySpec(lwdInstance.GetNamespace(), lwd.config().Ports["envoy"]),
 	}
 
 	var specObj string
 	var err error
 
-	fileTemplate := instResource.GetFileTemplate()
-	if action == "stopped" {
+	fileTemplate := lwd.fileTemplate(lwdInstance.Version)
+	if action == ztypes.EventActionStopInstance {
 		specObj, err = fileTemplate.ExecuteTemplate("INGRESS_STOPPED", lwdSpec)
 	} else {
 		specObj, err = fileTemplate.ExecuteTemplate("INGRESS", lwdSpec)
@@ -294,52 +461,100 @@ func (lwd *LWDInstanceResourceManager) CreateIngressAsset(ctx context.Context, p
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	object, err := helper.CreateYAMLObject(specObj)
-	if err != nil {
-		logger.Errorf(ctx, "Lightwalletd templates for version %s failed - %s", lwdInstance.Version, err)
-		return nil, errs.ErrInstanceResourceFailed
-	}
-
-	return object, nil
+	return reconcileIngress(ctx, projIngress, ingress.Route, specObj, action, func() (string, error) {
+		return fileTemplate.ExecuteTemplate("PROJECT_INGRESS", lwdSpec)
+	})
 }
 
 func (lwd *LWDInstanceResourceManager) CreateSnapshotAssets(ctx context.Context, instance entity.InstanceIF, volume string) ([]*unstructured.Unstructured, error) {
 
 	var req object.SnapshotRequest
 
-	lwdInstance := instance.(*entity.LWDInstance)
-	appRsc := vars.ManagerFactory.GetAppResourceManager(ctx)
+	lwdInstance, ok := asLWDInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	appRsc := lwd.opts.appManager(ctx)
 	req.Namespace = lwdInstance.GetNamespace()
 	req.Volume = volume
 	req.VolumeName = lwdInstance.DataVolume.Name
 	req.Labels = helper.CreateInstanceLabels(lwdInstance)
 
-	return appRsc.CreateSnapshotAsset(ctx, &req)
+	objects, err := appRsc.CreateSnapshotAsset(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
 }
 
 func (lwd *LWDInstanceResourceManager) CreateSnapshotScheduleAssets(ctx context.Context, instance entity.InstanceIF, volume string, scheduleType ztypes.ZBIBackupScheduleType) ([]*unstructured.Unstructured, error) {
 
 	var req object.SnapshotScheduleRequest
 
-	lwdInstance := instance.(*entity.LWDInstance)
-	appRsc := vars.ManagerFactory.GetAppResourceManager(ctx)
+	lwdInstance, ok := asLWDInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	appRsc := lwd.opts.appManager(ctx)
 	req.Namespace = lwdInstance.GetNamespace()
 	req.Volume = volume
 	req.Schedule = scheduleType
 	req.VolumeName = lwdInstance.DataVolume.Name
 	req.Labels = helper.CreateInstanceLabels(lwdInstance)
 
-	return appRsc.CreateSnapshotScheduleAsset(ctx, &req)
+	objects, err := appRsc.CreateSnapshotScheduleAsset(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
 }
 
+// CreateRotationAssets renders the zcash.conf of the server again after the credentials of its zcash node
+// are rotated. The changed ConfigMap restarts the server, which then reads the new credentials.
 func (lwd *LWDInstanceResourceManager) CreateRotationAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
-	return []*unstructured.Unstructured{}, nil
+	lwdInstance, ok := asLWDInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := lwd.GetInstanceResources(lwdInstance.Version)
+	if !ok {
+		logger.Errorf(ctx, "Lightwalletd resource not available for %s", lwdInstance.Version)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	instanceSpec, err := lwd.instanceSpec(ctx, lwdInstance, instResource)
+	if err != nil {
+		return nil, err
+	}
+	instanceSpec.CredentialsRotated = lwd.opts.now().UTC().Format(time.RFC3339)
+
+	var specArr []string
+
+	fileTemplate := lwd.fileTemplate(lwdInstance.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(LWD_TEMPLATES[rotationOperation], instanceSpec)
+	if err != nil {
+		logger.Errorf(ctx, "Lightwalletd templates for version %s failed - %s", lwdInstance.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	objects, err := helper.CreateYAMLObjects(specArr)
+	if err != nil {
+		logger.Errorf(ctx, "Lightwalletd templates for version %s failed - %s", lwdInstance.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
 }
 
 func (lwd *LWDInstanceResourceManager) UnmarshalBSONDetails(ctx context.Context, value bson.Raw) (entity.InstanceIF, error) {
 	logger.Tracef(ctx, "Unmarshaling Lightwalletd server instance details ............. %s", value.String())
 
-	var lwdInstance entity.LWDInstance
+	var lwdInstance LWDInstance
 	if err := bson.Unmarshal(value, &lwdInstance); err != nil {
 		return nil, err
 	}
@@ -24,7 +24,7 @@ func Test_NewLWDInstanceResourceManager(t *testing.T) {
 	assert.True(t, ok)
 	assert.NotNil(t, lwdConfig)
 
-	lwdResource, err := NewLWDInstanceResourceManager(lwdConfig)
+	lwdResource, err := NewLWDInstanceResourceManager(lwdConfig, testOptions())
 	assert.NoError(t, err)
 	assert.NotNil(t, lwdResource)
 }
@@ -35,7 +35,7 @@ func Test_GetLWDInstanceResources(t *testing.T) {
 	test.InitTest(ctx)
 
 	lwdConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeLWD)
-	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig)
+	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig, testOptions())
 	lwdManager := lwdResource.(*LWDInstanceResourceManager)
 	for version, _ := range lwdManager.lwdConfig.Versions {
 		cfg, ok := lwdResource.GetInstanceResources(version)
@@ -50,7 +50,7 @@ func Test_CreateLWDInstanceRequest(t *testing.T) {
 	test.InitTest(ctx)
 
 	lwdConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeLWD)
-	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig)
+	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig, testOptions())
 	//	lwdManager := lwdResource.(*LWDInstanceResourceManager)
 
 	var input struct {
@@ -85,9 +85,9 @@ func Test_CreateLWDInstance(t *testing.T) {
 	test.InitTest(ctx)
 
 	lwdConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeLWD)
-	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig)
+	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig, testOptions())
 
-	var lwdReq = object.LWDInstanceRequest{
+	var lwdReq = LWDInstanceRequest{LWDInstanceRequest: object.LWDInstanceRequest{
 		InstanceRequest: object.InstanceRequest{
 			Name:           "lwd-instance",
 			Version:        "v1",
@@ -97,7 +97,7 @@ func Test_CreateLWDInstance(t *testing.T) {
 			DataSource:     "",
 		},
 		ZcashInstance: "zcash-main-1.project.svc.cluster.local",
-	}
+	}}
 
 	lwdInstance, err := lwdResource.CreateInstance(ctx, &data.Project1, lwdReq)
 	assert.NoError(t, err)
@@ -115,7 +115,7 @@ func Test_CreateLWDDeploymentResourceAssets(t *testing.T) {
 	test.InitTest(ctx)
 
 	lwdConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeLWD)
-	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig)
+	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig, testOptions())
 
 	appManager := vars.ManagerFactory.GetAppResourceManager(ctx).(*rsc.FakeAppResourceManager)
 	appManager.FakeCreateVolumeAsset = func(ctx context.Context, volumes ...spec.VolumeSpec) ([]*unstructured.Unstructured, error) {
@@ -131,3 +131,31 @@ func Test_CreateLWDDeploymentResourceAssets(t *testing.T) {
 
 	//	t.Logf("LWD Objects: %s", utils.MarshalIndentObject(objects))
 }
+
+func Test_CreateLWDIngressAsset(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	lwdConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeLWD)
+	lwdResource, _ := NewLWDInstanceResourceManager(lwdConfig, testOptions())
+
+	// The project ingress is created from PROJECT_INGRESS when there is none yet.
+	projIngress, err := lwdResource.CreateIngressAsset(ctx, nil, data.LwdInstance1, ztypes.EventActionCreate)
+	assert.NoError(t, err)
+	assert.Equal(t, "project-proxy", projIngress.GetName())
+
+	routes, _, _ := unstructured.NestedSlice(projIngress.Object, "spec", "routes")
+	assert.Len(t, routes, 1)
+
+	projIngress, err = lwdResource.CreateIngressAsset(ctx, projIngress, data.LwdInstance1, ztypes.EventActionStopInstance)
+	assert.NoError(t, err)
+	routes, _, _ = unstructured.NestedSlice(projIngress.Object, "spec", "routes")
+	assert.Len(t, routes, 1)
+	assert.Contains(t, routes[0], "directResponsePolicy")
+
+	projIngress, err = lwdResource.CreateIngressAsset(ctx, projIngress, data.LwdInstance1, ztypes.EventActionDelete)
+	assert.NoError(t, err)
+	routes, _, _ = unstructured.NestedSlice(projIngress.Object, "spec", "routes")
+	assert.Empty(t, routes)
+}
@@ -0,0 +1,209 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	maxLabelNameLength   = 63
+	maxLabelPrefixLength = 253
+	maxLabelValueLength  = 63
+	maxAnnotationsSize   = 256 * 1024
+)
+
+var (
+	// RESERVED_LABELS are set by the platform and select its workloads. They cannot be set by users.
+	RESERVED_LABELS = map[string]bool{
+		"platform": true,
+		"project":  true,
+		"instance": true,
+		"type":     true,
+		"version":  true,
+		"owner":    true,
+		"network":  true,
+		"app":      true,
+	}
+
+	labelNameRegexp  = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	labelValueRegexp = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?)?$`)
+	dns1123Subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+	// podTemplatePaths locates the pod template of the workload kinds the templates render.
+	podTemplatePaths = map[string][]string{
+		"Deployment":  {"spec", "template"},
+		"StatefulSet": {"spec", "template"},
+		"DaemonSet":   {"spec", "template"},
+		"Job":         {"spec", "template"},
+		"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+	}
+)
+
+// Metadata holds the user-defined labels and annotations of a project or instance.
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty" bson:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" bson:"annotations,omitempty"`
+}
+
+// validateMetadata checks user-defined labels and annotations against the Kubernetes syntax and
+// rejects labels reserved by the platform.
+func validateMetadata(errors *ValidationErrors, field string, labels, annotations map[string]string) {
+	var prefix string
+	if len(field) > 0 {
+		prefix = field + "."
+	}
+
+	for _, key := range sortedKeys(labels) {
+		name := fmt.Sprintf("%slabels[%s]", prefix, key)
+		if RESERVED_LABELS[key] {
+			errors.Add(name, key, "is reserved by the platform")
+			continue
+		}
+		validateMetadataKey(errors, name, key)
+
+		value := labels[key]
+		if len(value) > maxLabelValueLength {
+			errors.Add(name, value, "must be no more than %d characters", maxLabelValueLength)
+		} else if !labelValueRegexp.MatchString(value) {
+			errors.Add(name, value, "must be empty or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character")
+		}
+	}
+
+	var size int
+	for _, key := range sortedKeys(annotations) {
+		validateMetadataKey(errors, fmt.Sprintf("%sannotations[%s]", prefix, key), key)
+		size += len(key) + len(annotations[key])
+	}
+	if size > maxAnnotationsSize {
+		errors.Add(prefix+"annotations", nil, "must have a total size of no more than %d bytes", maxAnnotationsSize)
+	}
+}
+
+func validateMetadataKey(errors *ValidationErrors, field, key string) {
+	name := key
+	if index := strings.LastIndex(key, "/"); index >= 0 {
+		prefix := key[:index]
+		name = key[index+1:]
+		if len(prefix) == 0 || len(prefix) > maxLabelPrefixLength || !dns1123Subdomain.MatchString(prefix) {
+			errors.Add(field, key, "must have a DNS subdomain prefix of no more than %d characters", maxLabelPrefixLength)
+		}
+	}
+
+	if len(name) == 0 || len(name) > maxLabelNameLength || !labelNameRegexp.MatchString(name) {
+		errors.Add(field, key, "must have a name of no more than %d alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character", maxLabelNameLength)
+	}
+}
+
+// mergeMetadata returns the entries of base overridden by those of overrides.
+func mergeMetadata(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+
+	var merged = make(map[string]string, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
+// applyMetadata adds user-defined labels and annotations to objects and to the pod templates of
+// workloads. Entries already set by the templates are kept, and selectors are never changed.
+func applyMetadata(objects []*unstructured.Unstructured, labels, annotations map[string]string) []*unstructured.Unstructured {
+	if len(labels) == 0 && len(annotations) == 0 {
+		return objects
+	}
+
+	for _, object := range objects {
+		object.SetLabels(addMissing(object.GetLabels(), labels))
+		object.SetAnnotations(addMissing(object.GetAnnotations(), annotations))
+
+		if path, ok := podTemplatePaths[object.GetKind()]; ok {
+			addTemplateMetadata(object, append(append([]string{}, path...), "metadata", "labels"), labels)
+			addTemplateMetadata(object, append(append([]string{}, path...), "metadata", "annotations"), annotations)
+		}
+	}
+
+	return objects
+}
+
+// applyInstanceMetadata adds the labels and annotations recorded on instance, which include those of its
+// project, to objects.
+func applyInstanceMetadata(instance entity.InstanceIF, objects []*unstructured.Unstructured) []*unstructured.Unstructured {
+	if details := detailsOf(instance); details != nil {
+		return applyMetadata(objects, details.Labels, details.Annotations)
+	}
+	return objects
+}
+
+// validateInstanceMetadata checks the labels and annotations of an instance request.
+func validateInstanceMetadata(ctx context.Context, request object.InstanceRequestIF) error {
+	var errors ValidationErrors
+	metadata := requestDetailsOf(request).Metadata
+	validateMetadata(&errors, "", metadata.Labels, metadata.Annotations)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Instance request %s is invalid - %s", request.GetName(), err)
+		return err
+	}
+	return nil
+}
+
+// setInstanceMetadata records on instance the labels and annotations of its project, overridden by
+// those of the request.
+func setInstanceMetadata(settings ProjectSettings, instance entity.InstanceIF, request object.InstanceRequestIF) {
+	if details := detailsOf(instance); details != nil {
+		metadata := requestDetailsOf(request).Metadata
+		details.Labels = mergeMetadata(settings.Labels, metadata.Labels)
+		details.Annotations = mergeMetadata(settings.Annotations, metadata.Annotations)
+	}
+}
+
+func addTemplateMetadata(object *unstructured.Unstructured, path []string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	current, _, _ := unstructured.NestedStringMap(object.Object, path...)
+	merged := addMissing(current, values)
+
+	var fields = make(map[string]interface{}, len(merged))
+	for key, value := range merged {
+		fields[key] = value
+	}
+	_ = unstructured.SetNestedField(object.Object, fields, path...)
+}
+
+func addMissing(current, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return current
+	}
+
+	var merged = make(map[string]string, len(current)+len(values))
+	for key, value := range values {
+		merged[key] = value
+	}
+	for key, value := range current {
+		merged[key] = value
+	}
+	return merged
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys = make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
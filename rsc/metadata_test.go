@@ -0,0 +1,89 @@
+package rsc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_ValidateMetadata(t *testing.T) {
+	var errors ValidationErrors
+	validateMetadata(&errors, "", map[string]string{"team": "payments", "example.com/tier": "gold"},
+		map[string]string{"example.com/contact": "ops@example.com"})
+	assert.NoError(t, errors.Err())
+
+	errors = nil
+	validateMetadata(&errors, "", map[string]string{
+		"project":       "other",
+		"-team":         "payments",
+		"Bad_/tier":     "gold",
+		"cost":          "not valid",
+		"example.com/a": "",
+	}, nil)
+	assert.Len(t, errors, 4)
+
+	var fields []string
+	for _, e := range errors {
+		fields = append(fields, e.Field)
+	}
+	assert.Contains(t, fields, "labels[project]")
+	assert.Contains(t, fields, "labels[-team]")
+	assert.Contains(t, fields, "labels[Bad_/tier]")
+	assert.Contains(t, fields, "labels[cost]")
+}
+
+func Test_MergeMetadata(t *testing.T) {
+	assert.Nil(t, mergeMetadata(nil, nil))
+
+	merged := mergeMetadata(map[string]string{"team": "payments", "tier": "gold"}, map[string]string{"tier": "silver"})
+	assert.Equal(t, map[string]string{"team": "payments", "tier": "silver"}, merged)
+}
+
+func Test_ApplyMetadata(t *testing.T) {
+	var deployment unstructured.Unstructured
+	deployment.SetKind("Deployment")
+	deployment.SetName("zcashd-node1")
+	deployment.SetLabels(map[string]string{"instance": "node1", "team": "platform"})
+	_ = unstructured.SetNestedStringMap(deployment.Object, map[string]string{"instance": "node1"}, "spec", "selector", "matchLabels")
+	_ = unstructured.SetNestedStringMap(deployment.Object, map[string]string{"instance": "node1"}, "spec", "template", "metadata", "labels")
+
+	var volume unstructured.Unstructured
+	volume.SetKind("PersistentVolumeClaim")
+	volume.SetName("zcash-data-node1")
+
+	objects := applyMetadata([]*unstructured.Unstructured{&deployment, &volume},
+		map[string]string{"team": "payments", "tier": "gold"}, map[string]string{"example.com/contact": "ops"})
+
+	assert.Equal(t, map[string]string{"instance": "node1", "team": "platform", "tier": "gold"}, objects[0].GetLabels())
+	assert.Equal(t, map[string]string{"example.com/contact": "ops"}, objects[0].GetAnnotations())
+
+	labels, _, _ := unstructured.NestedStringMap(objects[0].Object, "spec", "template", "metadata", "labels")
+	assert.Equal(t, map[string]string{"instance": "node1", "team": "payments", "tier": "gold"}, labels)
+
+	annotations, _, _ := unstructured.NestedStringMap(objects[0].Object, "spec", "template", "metadata", "annotations")
+	assert.Equal(t, map[string]string{"example.com/contact": "ops"}, annotations)
+
+	selector, _, _ := unstructured.NestedStringMap(objects[0].Object, "spec", "selector", "matchLabels")
+	assert.Equal(t, map[string]string{"instance": "node1"}, selector)
+
+	assert.Equal(t, map[string]string{"team": "payments", "tier": "gold"}, objects[1].GetLabels())
+	_, found, _ := unstructured.NestedMap(objects[1].Object, "spec")
+	assert.False(t, found)
+}
+
+func Test_SetInstanceMetadata(t *testing.T) {
+	settings := ProjectSettings{Metadata: Metadata{Labels: map[string]string{"team": "payments", "tier": "gold"}}}
+	request := ZebraNodeInstanceRequest{InstanceRequestDetails: InstanceRequestDetails{
+		Metadata: Metadata{Labels: map[string]string{"tier": "silver"}, Annotations: map[string]string{"example.com/contact": "ops"}}}}
+
+	zebra := &ZebraInstance{}
+	setInstanceMetadata(settings, zebra, request)
+	assert.Equal(t, map[string]string{"team": "payments", "tier": "silver"}, zebra.Labels)
+	assert.Equal(t, map[string]string{"example.com/contact": "ops"}, zebra.Annotations)
+
+	assert.NoError(t, validateInstanceMetadata(context.Background(), request))
+	request.Labels["project"] = "other"
+	assert.Error(t, validateInstanceMetadata(context.Background(), request))
+}
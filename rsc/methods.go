@@ -0,0 +1,71 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/config"
+)
+
+var rpcMethodPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// validateMethodGroups checks that groups are method groups of instResource. Instances without groups
+// accept every method.
+func validateMethodGroups(errors *ValidationErrors, instResource *config.VersionedResourceConfig, groups []string) {
+	var seen = make(map[string]bool, len(groups))
+	for index, group := range groups {
+		field := fmt.Sprintf("methodGroups[%d]", index)
+		if _, ok := instResource.Methods[group]; !ok {
+			errors.Add(field, group, "is not a method group of version %s", instResource.Version)
+		} else if seen[group] {
+			errors.Add(field, group, "is listed more than once")
+		}
+		seen[group] = true
+	}
+}
+
+// allowedMethods returns the sorted JSON-RPC methods of groups. It returns nil, allowing every method,
+// when there are no groups.
+func allowedMethods(ctx context.Context, instResource *config.VersionedResourceConfig, groups []string) ([]string, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	var methods = make(map[string]bool)
+	for _, group := range groups {
+		groupMethods, ok := instResource.Methods[group]
+		if !ok {
+			logger.Errorf(ctx, "Method group %s not available for version %s", group, instResource.Version)
+			return nil, errs.ErrInstanceResourceFailed
+		}
+
+		for _, method := range groupMethods {
+			if !rpcMethodPattern.MatchString(method) {
+				logger.Errorf(ctx, "Method %s of group %s is not a valid method name", method, group)
+				return nil, errs.ErrInstanceResourceFailed
+			}
+			methods[method] = true
+		}
+	}
+
+	var allowed = make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed, nil
+}
+
+// sampleMethods returns every method of instResource, so that dry renders include the method filter.
+func sampleMethods(instResource *config.VersionedResourceConfig) []string {
+	var methods []string
+	for _, groupMethods := range instResource.Methods {
+		methods = append(methods, groupMethods...)
+	}
+	sort.Strings(methods)
+	return methods
+}
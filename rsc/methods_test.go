@@ -0,0 +1,52 @@
+package rsc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/config"
+)
+
+func sampleMethodConfig() *config.VersionedResourceConfig {
+	return &config.VersionedResourceConfig{
+		Version: "v1",
+		Methods: map[string][]string{
+			"blockchain":      {"getblockcount", "getblock", "z_gettreestate"},
+			"rawtransactions": {"getrawtransaction", "sendrawtransaction"},
+			"control":         {"getinfo", "stop"},
+			"broken":          {"getinfo\"] = true, [\"stop"},
+		},
+	}
+}
+
+func Test_ValidateMethodGroups(t *testing.T) {
+	var errors ValidationErrors
+	validateMethodGroups(&errors, sampleMethodConfig(), []string{"blockchain", "rawtransactions"})
+	validateMethodGroups(&errors, sampleMethodConfig(), nil)
+	assert.NoError(t, errors.Err())
+
+	validateMethodGroups(&errors, sampleMethodConfig(), []string{"blockchain", "wallet", "blockchain"})
+	assert.Len(t, errors, 2)
+	assert.Equal(t, "methodGroups[1]", errors[0].Field)
+	assert.Equal(t, "methodGroups[2]", errors[1].Field)
+}
+
+func Test_AllowedMethods(t *testing.T) {
+	ctx := context.Background()
+
+	methods, err := allowedMethods(ctx, sampleMethodConfig(), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, methods)
+
+	methods, err = allowedMethods(ctx, sampleMethodConfig(), []string{"rawtransactions", "blockchain"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"getblock", "getblockcount", "getrawtransaction", "sendrawtransaction", "z_gettreestate"}, methods)
+	assert.NotContains(t, methods, "stop")
+
+	_, err = allowedMethods(ctx, sampleMethodConfig(), []string{"wallet"})
+	assert.Error(t, err)
+
+	_, err = allowedMethods(ctx, sampleMethodConfig(), []string{"broken"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package rsc
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	DEFAULT_MINER_THREADS = 1
+	MAX_MINER_THREADS     = 64
+)
+
+var (
+	// MINER_ADDRESS_PATTERNS are the transparent (P2PKH and P2SH) and Sapling addresses each network pays
+	// mining rewards to. Regtest chains use the testnet transparent prefixes. Checksums are verified by
+	// zcashd when it starts.
+	MINER_ADDRESS_PATTERNS = map[ztypes.NetworkType][]*regexp.Regexp{
+		ztypes.NetworkTypeMain: {
+			regexp.MustCompile(`^t[13][1-9A-HJ-NP-Za-km-z]{33}$`),
+			regexp.MustCompile(`^zs1[02-9ac-hj-np-z]{75}$`),
+		},
+		ztypes.NetworkTypeTest: {
+			regexp.MustCompile(`^t[m2][1-9A-HJ-NP-Za-km-z]{33}$`),
+			regexp.MustCompile(`^ztestsapling1[02-9ac-hj-np-z]{75}$`),
+		},
+		NetworkTypeRegtest: {
+			regexp.MustCompile(`^t[m2][1-9A-HJ-NP-Za-km-z]{33}$`),
+			regexp.MustCompile(`^zregtestsapling1[02-9ac-hj-np-z]{75}$`),
+		},
+	}
+)
+
+// validateMining checks the mining settings of request. Mainnet projects only run miners when their
+// settings allow mining.
+func validateMining(errors *ValidationErrors, project *entity.Project, settings ProjectSettings, request ZcashNodeInstanceRequest) {
+	if !request.Miner {
+		if len(request.MinerAddress) > 0 {
+			errors.Add("minerAddress", request.MinerAddress, "requires miner to be enabled")
+		}
+		if request.MinerThreads != 0 {
+			errors.Add("minerThreads", request.MinerThreads, "requires miner to be enabled")
+		}
+		return
+	}
+
+	if project.GetNetwork() == ztypes.NetworkTypeMain && !settings.AllowMining {
+		errors.Add("miner", request.Miner, "is not allowed on %s projects that do not allow mining", ztypes.NetworkTypeMain)
+	}
+
+	if request.MinerThreads < 0 || request.MinerThreads > MAX_MINER_THREADS {
+		errors.Add("minerThreads", request.MinerThreads, "must be between 1 and %d", MAX_MINER_THREADS)
+	}
+
+	if len(request.MinerAddress) > 0 && !validMinerAddress(project.GetNetwork(), request.MinerAddress) {
+		errors.Add("minerAddress", request.MinerAddress, "is not a transparent or Sapling address of %s", project.GetNetwork())
+	}
+}
+
+func validMinerAddress(network ztypes.NetworkType, address string) bool {
+	for _, pattern := range MINER_ADDRESS_PATTERNS[network] {
+		if pattern.MatchString(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// minerThreads returns the number of threads a miner recorded with threads runs.
+func minerThreads(threads int32) int32 {
+	if threads <= 0 {
+		return DEFAULT_MINER_THREADS
+	}
+	return threads
+}
+
+// minerResources returns profile with a node container requesting at least one CPU for each mining
+// thread. profile is not changed.
+func minerResources(profile ResourceProfile, threads int32) ResourceProfile {
+	node, ok := profile["node"]
+	if !ok {
+		return profile
+	}
+
+	var sized = make(ResourceProfile, len(profile))
+	for container, resources := range profile {
+		sized[container] = resources
+	}
+
+	var cpu = resource.MustParse(strconv.Itoa(int(minerThreads(threads))))
+	var resources = *node
+	resources.RequestCPU = maxQuantity(resources.RequestCPU, cpu)
+	resources.LimitCPU = maxQuantity(resources.LimitCPU, cpu)
+	sized["node"] = &resources
+
+	return sized
+}
+
+// maxQuantity returns the larger of value and minimum. Values that cannot be parsed are replaced.
+func maxQuantity(value string, minimum resource.Quantity) string {
+	if quantity, err := resource.ParseQuantity(value); err == nil && quantity.Cmp(minimum) >= 0 {
+		return value
+	}
+	return minimum.String()
+}
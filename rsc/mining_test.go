@@ -0,0 +1,73 @@
+package rsc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const (
+	mainnetTransparentAddress = "t1Hsc1LR8yKnbbe3twRp88p6vFfC5t7DLbs"
+	mainnetSaplingAddress     = "zs1z7rejlpsa98s2rrrfkwmaxu53e4ue0ulcrw0h4x5g8jl04tak0d3mm47vdtahatqrlkngh9slya"
+	testnetTransparentAddress = "tmRMquNGL6CJMRjkH6Ekv7Dj6E3QNGW6jK4"
+)
+
+func Test_ValidMinerAddress(t *testing.T) {
+	assert.True(t, validMinerAddress(ztypes.NetworkTypeMain, mainnetTransparentAddress))
+	assert.True(t, validMinerAddress(ztypes.NetworkTypeMain, mainnetSaplingAddress))
+	assert.True(t, validMinerAddress(ztypes.NetworkTypeTest, testnetTransparentAddress))
+	assert.True(t, validMinerAddress(NetworkTypeRegtest, testnetTransparentAddress))
+
+	assert.False(t, validMinerAddress(ztypes.NetworkTypeTest, mainnetTransparentAddress))
+	assert.False(t, validMinerAddress(ztypes.NetworkTypeMain, testnetTransparentAddress))
+	assert.False(t, validMinerAddress(ztypes.NetworkTypeMain, mainnetTransparentAddress+"\ngen=0"))
+}
+
+func minerRequest(miner bool, threads int32, address string) ZcashNodeInstanceRequest {
+	return ZcashNodeInstanceRequest{
+		ZcashNodeInstanceRequest: object.ZcashNodeInstanceRequest{Miner: miner},
+		MinerThreads:             threads,
+		MinerAddress:             address,
+	}
+}
+
+func Test_ValidateMining(t *testing.T) {
+	mainnet := &entity.Project{Name: "project", Network: ztypes.NetworkTypeMain}
+	testnet := &entity.Project{Name: "project", Network: ztypes.NetworkTypeTest}
+
+	var errors ValidationErrors
+	validateMining(&errors, testnet, ProjectSettings{}, minerRequest(true, 4, testnetTransparentAddress))
+	validateMining(&errors, testnet, ProjectSettings{}, ZcashNodeInstanceRequest{})
+	assert.NoError(t, errors.Err())
+
+	validateMining(&errors, mainnet, ProjectSettings{}, minerRequest(true, 0, mainnetSaplingAddress))
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "miner", errors[0].Field)
+
+	errors = nil
+	allowed := ProjectSettings{AllowMining: true}
+	validateMining(&errors, mainnet, allowed, minerRequest(true, 0, mainnetSaplingAddress))
+	assert.NoError(t, errors.Err())
+
+	validateMining(&errors, mainnet, allowed, minerRequest(true, MAX_MINER_THREADS+1, testnetTransparentAddress))
+	validateMining(&errors, testnet, ProjectSettings{}, minerRequest(false, 2, testnetTransparentAddress))
+	assert.Len(t, errors, 4)
+}
+
+func Test_MinerResources(t *testing.T) {
+	profile := DefaultProfilePolicy().Profiles[ztypes.InstanceTypeZCASH][STANDARD_PROFILE]
+
+	sized := minerResources(profile, 0)
+	assert.Equal(t, "1", sized["node"].RequestCPU)
+	assert.Equal(t, "2", sized["node"].LimitCPU)
+
+	sized = minerResources(profile, 4)
+	assert.Equal(t, "4", sized["node"].RequestCPU)
+	assert.Equal(t, "4", sized["node"].LimitCPU)
+	assert.Equal(t, "4Gi", sized["node"].LimitMemory)
+	assert.Equal(t, profile["metrics"], sized["metrics"])
+	assert.Equal(t, "1", profile["node"].RequestCPU)
+}
@@ -0,0 +1,112 @@
+package rsc
+
+import (
+	"context"
+	"io/fs"
+	"time"
+
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/spec"
+	"github.com/zbitech/mgr/internal/helper"
+)
+
+const (
+	DEFAULT_INGRESS_NAMESPACE    = "projectcontour"
+	DEFAULT_MONITORING_NAMESPACE = "monitoring"
+)
+
+// Options carries the configuration the resource managers render assets with. Each manager keeps
+// its own copy so that differently configured factories can coexist in one process.
+type Options struct {
+	ResourceConfig *config.ResourceConfig
+	Policy         config.PolicyConfig
+	Envoy          config.EnvoyConfig
+	Features       config.FeaturesConfig
+
+	// Quotas sizes the ResourceQuota and LimitRange of each project namespace.
+	Quotas QuotaPolicy
+
+	// Profiles sizes the containers of instances.
+	Profiles ProfilePolicy
+
+	// Volumes sizes the volumes of instances.
+	Volumes VolumePolicy
+
+	// Probes configures when zcash nodes and lightwalletd servers are started and ready.
+	Probes ProbeOptions
+
+	// Authz configures the authorization server deployed with each project when access authorization is enabled.
+	Authz AuthzOptions
+
+	// IngressNamespace runs the ingress controller allowed to reach the envoy port of each instance.
+	IngressNamespace string
+
+	// MonitoringNamespace runs the metrics scrapers allowed to reach the metrics port of each instance.
+	// Metrics are not reachable from outside the project when it is empty.
+	MonitoringNamespace string
+
+	// Assets holds the versioned template files named in the resource config.
+	Assets fs.FS
+
+	// Clock returns the time recorded on created and updated entities.
+	Clock func() time.Time
+
+	// Factory provides the app resource manager used to render volumes and snapshots.
+	Factory interfaces.ResourceManagerFactoryIF
+
+	// CredentialOverlap keeps the replaced credentials of a zcash node valid for a while after they are
+	// rotated, so that the servers using the node can restart with the new ones. Replaced credentials stop
+	// working at once when it is zero.
+	CredentialOverlap time.Duration
+
+	// Instances lists the instances of a project. Nodes of regtest and auto-peering projects use it to
+	// find each other, and credential rotation to find the servers that depend on a node. Neither happens
+	// when it is nil.
+	Instances func(ctx context.Context, project string) ([]entity.InstanceIF, error)
+
+	// ProjectSettings returns the settings recorded with a project. Projects have no settings when it is nil.
+	ProjectSettings func(ctx context.Context, project string) (ProjectSettings, error)
+}
+
+// DefaultOptions returns options with the default policies and namespaces. Callers provide the resource
+// config, the app policies, the template assets and the factory.
+func DefaultOptions() Options {
+	return Options{
+		Quotas:              DefaultQuotaPolicy(),
+		Profiles:            DefaultProfilePolicy(),
+		Volumes:             DefaultVolumePolicy(),
+		Probes:              ProbeOptions{SyncThreshold: DEFAULT_SYNC_THRESHOLD, StartupTimeout: DEFAULT_STARTUP_TIMEOUT},
+		Authz:               AuthzOptions{DatabaseSecret: DEFAULT_AUTHZ_DATABASE_SECRET, DatabaseURLKey: DEFAULT_AUTHZ_DATABASE_URL_KEY},
+		IngressNamespace:    DEFAULT_INGRESS_NAMESPACE,
+		MonitoringNamespace: DEFAULT_MONITORING_NAMESPACE,
+		Clock:               time.Now,
+	}
+}
+
+func (o Options) now() time.Time {
+	if o.Clock == nil {
+		return time.Now()
+	}
+	return o.Clock()
+}
+
+func (o Options) appManager(ctx context.Context) interfaces.AppResourceManagerIF {
+	return o.Factory.GetAppResourceManager(ctx)
+}
+
+// envoySpec returns the envoy settings of an instance in namespace. Unless an authorization server is
+// configured, ext_authz is sent to the one deployed in the project namespace.
+func (o Options) envoySpec(namespace string, envoyServicePort int32) spec.EnvoySpec {
+	envoy := helper.CreateEnvoySpec(o.Envoy, o.Features.AccessAuthorizationEnabled, envoyServicePort)
+	if envoy.AccessAuthorization && len(envoy.AuthServerURL) == 0 {
+		envoy.AuthServerURL = authzServiceHost(namespace)
+		envoy.AuthServerPort = AUTHZ_SERVICE_PORT
+	}
+	return envoy
+}
+
+func (o Options) networkPolicySpec() NetworkPolicySpec {
+	return NetworkPolicySpec{IngressNamespace: o.IngressNamespace, MonitoringNamespace: o.MonitoringNamespace}
+}
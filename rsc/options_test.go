@@ -0,0 +1,31 @@
+package rsc
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/vars"
+)
+
+// testOptions returns the default options completed with the configuration the test setup loads into vars.
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.ResourceConfig = vars.ResourceConfig
+	opts.Policy = vars.AppConfig.Policy
+	opts.Envoy = vars.AppConfig.Envoy
+	opts.Features = vars.AppConfig.Features
+	opts.Assets = os.DirFS(vars.ASSET_PATH_DIRECTORY)
+	opts.Factory = vars.ManagerFactory
+	return opts
+}
+
+func Test_DefaultOptions(t *testing.T) {
+	opts := DefaultOptions()
+	assert.Nil(t, opts.ResourceConfig)
+	assert.Nil(t, opts.Assets)
+	assert.Nil(t, opts.Factory)
+	assert.Equal(t, DEFAULT_INGRESS_NAMESPACE, opts.IngressNamespace)
+	assert.Equal(t, DEFAULT_MONITORING_NAMESPACE, opts.MonitoringNamespace)
+	assert.NotEmpty(t, opts.Profiles.Profiles)
+}
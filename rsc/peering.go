@@ -0,0 +1,88 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const (
+	MAINNET_PEER_PORT = 8233
+	TESTNET_PEER_PORT = 18233
+)
+
+// zcashPeerPort returns the port zcash nodes on network listen on for peers.
+func zcashPeerPort(network ztypes.NetworkType) int32 {
+	switch network {
+	case ztypes.NetworkTypeMain:
+		return MAINNET_PEER_PORT
+	case NetworkTypeRegtest:
+		return REGTEST_PEER_PORT
+	}
+	return TESTNET_PEER_PORT
+}
+
+// peersWithSiblings reports whether zcash is peered with the other zcash nodes of its project. Regtest
+// nodes always are, since they have no other peers.
+func peersWithSiblings(zcash *ZcashInstance) bool {
+	return zcash.Network == NetworkTypeRegtest || zcash.AutoPeering
+}
+
+// validatePeers checks that peers are host or host:port addresses that can be written to zcash.conf.
+func validatePeers(errors *ValidationErrors, peers []string) {
+	for index, peer := range peers {
+		if !peerAddressPattern.MatchString(peer) {
+			errors.Add(fmt.Sprintf("peers[%d]", index), peer, "must be a host name or IP address with an optional port")
+		}
+	}
+}
+
+// validatePeerAddresses checks that peers are valid peer addresses that include a port, as zebrad needs.
+func validatePeerAddresses(errors *ValidationErrors, peers []string) {
+	for index, peer := range peers {
+		if !peerAddressPattern.MatchString(peer) {
+			errors.Add(fmt.Sprintf("peers[%d]", index), peer, "must be a host name or IP address with a port")
+			continue
+		}
+		_, port, err := net.SplitHostPort(peer)
+		if number, convErr := strconv.Atoi(port); err != nil || convErr != nil || number < 1 || number > 65535 {
+			errors.Add(fmt.Sprintf("peers[%d]", index), peer, "must include a port between 1 and 65535")
+		}
+	}
+}
+
+// siblingPeers returns the peer address of every other zcash instance of the project of zcash. Each node
+// adds the nodes that existed when it was rendered, so together they connect every pair of nodes.
+func (z *ZcashInstanceResourceManager) siblingPeers(ctx context.Context, zcash *ZcashInstance) ([]string, error) {
+	if z.opts.Instances == nil {
+		return nil, nil
+	}
+
+	instances, err := z.opts.Instances(ctx, zcash.GetProject())
+	if err != nil {
+		logger.Errorf(ctx, "Instances of project %s not available - %s", zcash.GetProject(), err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	var port = zcashPeerPort(zcash.Network)
+	var peers []string
+	for _, instance := range instances {
+		if instance.GetInstanceType() != ztypes.InstanceTypeZCASH || instance.GetName() == zcash.Name {
+			continue
+		}
+		peers = append(peers, zcashPeerAddress(instance.GetName(), zcash.GetNamespace(), port))
+	}
+
+	sort.Strings(peers)
+	return peers, nil
+}
+
+func zcashPeerAddress(name, namespace string, port int32) string {
+	return fmt.Sprintf("zcashd-svc-%s.%s.svc.cluster.local:%d", name, namespace, port)
+}
@@ -0,0 +1,81 @@
+package rsc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+func Test_ValidatePeers(t *testing.T) {
+	var errs ValidationErrors
+	validatePeers(&errs, []string{"node.example.com", "10.0.0.4:8233", "[2001:db8::1]:8233"})
+	assert.NoError(t, errs.Err())
+
+	validatePeers(&errs, []string{"node.example.com", "node.example.com\nrpcallowip=0.0.0.0/0", "-node", ""})
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "peers[1]", errs[0].Field)
+}
+
+func Test_ValidatePeerAddresses(t *testing.T) {
+	var errs ValidationErrors
+	validatePeerAddresses(&errs, []string{"node.example.com:8233", "10.0.0.4:8233", "[2001:db8::1]:8233"})
+	assert.NoError(t, errs.Err())
+
+	validatePeerAddresses(&errs, []string{"node.example.com", "10.0.0.4:0", "node.example.com:8233\nrpcallowip=0.0.0.0/0", "node.example.com:99999"})
+	assert.Len(t, errs, 4)
+	assert.Equal(t, "peers[0]", errs[0].Field)
+}
+
+func Test_SiblingPeers(t *testing.T) {
+	ctx := context.Background()
+	node := func(name string) *ZcashInstance {
+		return &ZcashInstance{ZcashInstance: entity.ZcashInstance{Instance: entity.Instance{Project: "project", Name: name,
+			Network: NetworkTypeRegtest, InstanceType: ztypes.InstanceTypeZCASH}}}
+	}
+	lwd := &entity.LWDInstance{Instance: entity.Instance{Project: "project", Name: "lwd1",
+		Network: NetworkTypeRegtest, InstanceType: ztypes.InstanceTypeLWD}}
+
+	var z ZcashInstanceResourceManager
+	peers, err := z.siblingPeers(ctx, node("node1"))
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+
+	z.opts.Instances = func(ctx context.Context, project string) ([]entity.InstanceIF, error) {
+		assert.Equal(t, "project", project)
+		return []entity.InstanceIF{node("node3"), lwd, node("node1"), node("node2")}, nil
+	}
+	peers, err = z.siblingPeers(ctx, node("node1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"zcashd-svc-node2.project.svc.cluster.local:18344",
+		"zcashd-svc-node3.project.svc.cluster.local:18344",
+	}, peers)
+
+	testnet := node("node1")
+	testnet.Network = ztypes.NetworkTypeTest
+	peers, err = z.siblingPeers(ctx, testnet)
+	assert.NoError(t, err)
+	assert.Equal(t, "zcashd-svc-node2.project.svc.cluster.local:18233", peers[0])
+
+	z.opts.Instances = func(ctx context.Context, project string) ([]entity.InstanceIF, error) {
+		return nil, errors.New("repository unavailable")
+	}
+	_, err = z.siblingPeers(ctx, node("node1"))
+	assert.Error(t, err)
+}
+
+func Test_PeersWithSiblings(t *testing.T) {
+	zcash := &ZcashInstance{ZcashInstance: entity.ZcashInstance{Instance: entity.Instance{Network: ztypes.NetworkTypeMain}}}
+	assert.False(t, peersWithSiblings(zcash))
+
+	zcash.AutoPeering = true
+	assert.True(t, peersWithSiblings(zcash))
+	assert.EqualValues(t, MAINNET_PEER_PORT, zcashPeerPort(zcash.Network))
+
+	zcash = &ZcashInstance{ZcashInstance: entity.ZcashInstance{Instance: entity.Instance{Network: NetworkTypeRegtest}}}
+	assert.True(t, peersWithSiblings(zcash))
+}
@@ -0,0 +1,89 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/config"
+)
+
+const (
+	// DEFAULT_SYNC_THRESHOLD is the getblockchaininfo verificationprogress a zcash node reports before
+	// it, and the lightwalletd servers reading from it, are ready.
+	DEFAULT_SYNC_THRESHOLD = 0.999
+
+	// DEFAULT_STARTUP_TIMEOUT is how long a node may take to fetch its parameters and load its block
+	// index before its RPC port answers.
+	DEFAULT_STARTUP_TIMEOUT = 6 * time.Hour
+
+	// PROBE_STARTUP_PERIOD is how often the startup probes run.
+	PROBE_STARTUP_PERIOD = 30 * time.Second
+
+	probeImage = "probe"
+)
+
+// ProbeOptions configures the startup and readiness probes of zcash nodes and lightwalletd servers.
+type ProbeOptions struct {
+	// SyncThreshold is the verification progress, between 0 and 1, a node reports before it receives
+	// traffic. DEFAULT_SYNC_THRESHOLD is used when it is zero.
+	SyncThreshold float64
+
+	// StartupTimeout is how long an instance may take to start before it is restarted.
+	// DEFAULT_STARTUP_TIMEOUT is used when it is zero.
+	StartupTimeout time.Duration
+}
+
+// ProbeSpec is what the probes and probe sidecar of the DEPLOYMENT templates are rendered with. The
+// sidecar asks the node at URL for getblockchaininfo with the credentials of the node.
+type ProbeSpec struct {
+	Image           string
+	URL             string
+	SyncThreshold   string
+	StartupPeriod   int32
+	StartupFailures int32
+}
+
+// probeSpec returns the probe settings of an instance of instResource whose node answers JSON-RPC at
+// host and port.
+func (o Options) probeSpec(ctx context.Context, instResource *config.VersionedResourceConfig, host string, port int32) (ProbeSpec, error) {
+	image := instResource.GetImage(probeImage)
+	if image == nil {
+		logger.Errorf(ctx, "Instance version %s does not define the %s image", instResource.Version, probeImage)
+		return ProbeSpec{}, errs.ErrInstanceResourceFailed
+	}
+
+	threshold := o.Probes.SyncThreshold
+	if threshold == 0 {
+		threshold = DEFAULT_SYNC_THRESHOLD
+	}
+	if threshold < 0 || threshold > 1 {
+		logger.Errorf(ctx, "Sync threshold %v is not between 0 and 1", threshold)
+		return ProbeSpec{}, errs.ErrInstanceResourceFailed
+	}
+
+	timeout := o.Probes.StartupTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_STARTUP_TIMEOUT
+	}
+
+	return ProbeSpec{
+		Image:           imageURL(image),
+		URL:             fmt.Sprintf("http://%s:%d/", host, port),
+		SyncThreshold:   strconv.FormatFloat(threshold, 'f', -1, 64),
+		StartupPeriod:   int32(PROBE_STARTUP_PERIOD / time.Second),
+		StartupFailures: startupFailures(timeout),
+	}, nil
+}
+
+// startupFailures returns the number of failed startup probes that take at least timeout.
+func startupFailures(timeout time.Duration) int32 {
+	failures := int32((timeout + PROBE_STARTUP_PERIOD - 1) / PROBE_STARTUP_PERIOD)
+	if failures < 1 {
+		return 1
+	}
+	return failures
+}
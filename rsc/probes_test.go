@@ -0,0 +1,50 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"testing"
+	"time"
+)
+
+func Test_ProbeSpec(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	zcashConfig, ok := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	assert.Truef(t, ok, "zcash resource not configured")
+	instResource := zcashConfig.Versions["v1"]
+
+	var opts Options
+	probe, err := opts.probeSpec(ctx, instResource, "127.0.0.1", 18232)
+	assert.NoError(t, err)
+	assert.Equal(t, imageURL(instResource.GetImage(probeImage)), probe.Image)
+	assert.Equal(t, "http://127.0.0.1:18232/", probe.URL)
+	assert.Equal(t, "0.999", probe.SyncThreshold)
+	assert.EqualValues(t, 30, probe.StartupPeriod)
+	assert.EqualValues(t, 720, probe.StartupFailures)
+
+	opts.Probes = ProbeOptions{SyncThreshold: 0.99995, StartupTimeout: 45 * time.Second}
+	probe, err = opts.probeSpec(ctx, instResource, "127.0.0.1", 18232)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.99995", probe.SyncThreshold)
+	assert.EqualValues(t, 2, probe.StartupFailures)
+
+	opts.Probes.SyncThreshold = 1.5
+	_, err = opts.probeSpec(ctx, instResource, "127.0.0.1", 18232)
+	assert.Error(t, err)
+
+	_, err = Options{}.probeSpec(ctx, &config.VersionedResourceConfig{Version: "v1"}, "127.0.0.1", 18232)
+	assert.Error(t, err)
+}
+
+func Test_StartupFailures(t *testing.T) {
+	assert.EqualValues(t, 1, startupFailures(time.Second))
+	assert.EqualValues(t, 1, startupFailures(PROBE_STARTUP_PERIOD))
+	assert.EqualValues(t, 2, startupFailures(PROBE_STARTUP_PERIOD+time.Second))
+	assert.EqualValues(t, 120, startupFailures(time.Hour))
+}
@@ -0,0 +1,105 @@
+package rsc
+
+import (
+	"context"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const (
+	SMALL_PROFILE    = "small"
+	STANDARD_PROFILE = "standard"
+	ARCHIVE_PROFILE  = "archive"
+)
+
+// ContainerResources are the requests and limits of a container. Quantities use the Kubernetes notation
+// ("2", "500m", "4Gi"); empty values are left out.
+type ContainerResources struct {
+	RequestCPU    string `json:"requestCpu" yaml:"requestCpu"`
+	RequestMemory string `json:"requestMemory" yaml:"requestMemory"`
+	LimitCPU      string `json:"limitCpu" yaml:"limitCpu"`
+	LimitMemory   string `json:"limitMemory" yaml:"limitMemory"`
+}
+
+// ResourceProfile sizes the containers of an instance, keyed by container name. Containers without an
+// entry get the defaults of the project LimitRange.
+type ResourceProfile map[string]*ContainerResources
+
+// ProfilePolicy holds the resource profiles of each instance type. Instances use Default unless their
+// request names another profile.
+type ProfilePolicy struct {
+	Default  string                                             `json:"default" yaml:"default"`
+	Profiles map[ztypes.InstanceType]map[string]ResourceProfile `json:"profiles" yaml:"profiles"`
+}
+
+// DefaultProfilePolicy sizes zcash nodes and lightwalletd servers for light use (small), a pruned or
+// regular node (standard) and an archive node serving indexes and wallets (archive).
+func DefaultProfilePolicy() ProfilePolicy {
+	var sidecars = func(profile ResourceProfile) ResourceProfile {
+		profile["envoy-proxy"] = &ContainerResources{RequestCPU: "50m", RequestMemory: "64Mi", LimitCPU: "500m", LimitMemory: "256Mi"}
+		profile["probe"] = &ContainerResources{RequestCPU: "10m", RequestMemory: "16Mi", LimitCPU: "100m", LimitMemory: "64Mi"}
+		return profile
+	}
+
+	var zcash = func(requestCPU, requestMemory, limitCPU, limitMemory string) ResourceProfile {
+		return sidecars(ResourceProfile{
+			"init":            {RequestCPU: "10m", RequestMemory: "16Mi", LimitCPU: "100m", LimitMemory: "64Mi"},
+			"node":            {RequestCPU: requestCPU, RequestMemory: requestMemory, LimitCPU: limitCPU, LimitMemory: limitMemory},
+			"metrics":         {RequestCPU: "50m", RequestMemory: "32Mi", LimitCPU: "250m", LimitMemory: "64Mi"},
+			"block-generator": {RequestCPU: "10m", RequestMemory: "16Mi", LimitCPU: "100m", LimitMemory: "64Mi"},
+		})
+	}
+
+	var lwd = func(requestCPU, requestMemory, limitCPU, limitMemory string) ResourceProfile {
+		return sidecars(ResourceProfile{
+			"init":         {RequestCPU: "10m", RequestMemory: "16Mi", LimitCPU: "100m", LimitMemory: "64Mi"},
+			"lightwalletd": {RequestCPU: requestCPU, RequestMemory: requestMemory, LimitCPU: limitCPU, LimitMemory: limitMemory},
+		})
+	}
+
+	return ProfilePolicy{
+		Default: STANDARD_PROFILE,
+		Profiles: map[ztypes.InstanceType]map[string]ResourceProfile{
+			ztypes.InstanceTypeZCASH: {
+				SMALL_PROFILE:    zcash("500m", "2Gi", "1", "3Gi"),
+				STANDARD_PROFILE: zcash("1", "3Gi", "2", "4Gi"),
+				ARCHIVE_PROFILE:  zcash("2", "8Gi", "4", "16Gi"),
+			},
+			ztypes.InstanceTypeLWD: {
+				SMALL_PROFILE:    lwd("100m", "256Mi", "500m", "512Mi"),
+				STANDARD_PROFILE: lwd("250m", "512Mi", "1", "1Gi"),
+				ARCHIVE_PROFILE:  lwd("500m", "1Gi", "2", "4Gi"),
+			},
+		},
+	}
+}
+
+// profileName returns the profile an instance of iType gets when its request asks for requested.
+func (p ProfilePolicy) profileName(errors *ValidationErrors, iType ztypes.InstanceType, requested string) string {
+	name := requested
+	if len(name) == 0 {
+		name = p.Default
+	}
+
+	if _, ok := p.Profiles[iType][name]; !ok {
+		errors.Add("profile", name, "is not a resource profile of %s instances", iType)
+	}
+	return name
+}
+
+// profile returns the resource profile recorded on an instance of iType. Instances created before
+// profiles were recorded get the default profile.
+func (p ProfilePolicy) profile(ctx context.Context, iType ztypes.InstanceType, name string) (ResourceProfile, error) {
+	if len(name) == 0 {
+		name = p.Default
+	}
+
+	profile, ok := p.Profiles[iType][name]
+	if !ok {
+		logger.Errorf(ctx, "Resource profile %s not available for %s instances", name, iType)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+	return profile, nil
+}
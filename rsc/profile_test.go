@@ -0,0 +1,55 @@
+package rsc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+func Test_DefaultProfilePolicy(t *testing.T) {
+	policy := DefaultProfilePolicy()
+
+	for _, iType := range []ztypes.InstanceType{ztypes.InstanceTypeZCASH, ztypes.InstanceTypeLWD} {
+		for _, name := range []string{SMALL_PROFILE, STANDARD_PROFILE, ARCHIVE_PROFILE} {
+			profile, ok := policy.Profiles[iType][name]
+			assert.Truef(t, ok, "%s profile missing for %s", name, iType)
+			for container, resources := range profile {
+				assert.NotEmptyf(t, resources.LimitCPU, "%s/%s/%s has no cpu limit", iType, name, container)
+				assert.NotEmptyf(t, resources.LimitMemory, "%s/%s/%s has no memory limit", iType, name, container)
+			}
+		}
+	}
+
+	assert.Equal(t, "4Gi", policy.Profiles[ztypes.InstanceTypeZCASH][STANDARD_PROFILE]["node"].LimitMemory)
+}
+
+func Test_ProfileName(t *testing.T) {
+	policy := DefaultProfilePolicy()
+
+	var errors ValidationErrors
+	assert.Equal(t, STANDARD_PROFILE, policy.profileName(&errors, ztypes.InstanceTypeZCASH, ""))
+	assert.Equal(t, ARCHIVE_PROFILE, policy.profileName(&errors, ztypes.InstanceTypeLWD, ARCHIVE_PROFILE))
+	assert.NoError(t, errors.Err())
+
+	policy.profileName(&errors, ztypes.InstanceTypeZCASH, "huge")
+	policy.profileName(&errors, InstanceTypeZEBRA, "")
+	assert.Len(t, errors, 2)
+}
+
+func Test_Profile(t *testing.T) {
+	ctx := context.Background()
+	policy := DefaultProfilePolicy()
+
+	profile, err := policy.profile(ctx, ztypes.InstanceTypeZCASH, "")
+	assert.NoError(t, err)
+	assert.Equal(t, policy.Profiles[ztypes.InstanceTypeZCASH][STANDARD_PROFILE], profile)
+
+	profile, err = policy.profile(ctx, ztypes.InstanceTypeLWD, SMALL_PROFILE)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", profile["lightwalletd"].LimitCPU)
+
+	_, err = policy.profile(ctx, ztypes.InstanceTypeZCASH, "removed")
+	assert.Error(t, err)
+}
@@ -0,0 +1,398 @@
+package rsc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/internal/helper"
+	"go.mongodb.org/mongo-driver/bson"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// BUNDLE_FORMAT_VERSION is increased whenever the layout of a bundle changes in a way older
+	// releases cannot read.
+	BUNDLE_FORMAT_VERSION = 1
+
+	bundleMetadataFile = "metadata.json"
+	bundleManifestDir  = "manifests"
+)
+
+// VolumeSnapshotReference names the snapshot a volume of an instance is restored from.
+type VolumeSnapshotReference struct {
+	Instance string `json:"instance"`
+	Volume   string `json:"volume"`
+	Snapshot string `json:"snapshot"`
+}
+
+// BundleMetadata is stored as metadata.json at the root of a bundle. Instances are kept in MongoDB
+// extended JSON so that they decode into the same entities they were exported from.
+type BundleMetadata struct {
+	FormatVersion int                       `json:"formatVersion"`
+	ExportedAt    time.Time                 `json:"exportedAt"`
+	Project       *entity.Project           `json:"project"`
+	Settings      ProjectSettings           `json:"settings"`
+	Instances     []json.RawMessage         `json:"instances"`
+	Volumes       []VolumeSnapshotReference `json:"volumes"`
+	Manifests     []string                  `json:"manifests"`
+}
+
+// ProjectBundle is the content of an imported bundle. Assets are rendered with the local templates and
+// policy; instance volumes are restored from the snapshots in Volumes.
+type ProjectBundle struct {
+	Project   *entity.Project
+	Settings  ProjectSettings
+	Instances []entity.InstanceIF
+	Volumes   []VolumeSnapshotReference
+	Assets    []*unstructured.Unstructured
+}
+
+// ProjectBundleManager is implemented by project resource managers that can move a project between
+// clusters.
+type ProjectBundleManager interface {
+	ExportProject(ctx context.Context, project *entity.Project, instances []entity.InstanceIF,
+		snapshots []VolumeSnapshotReference, w io.Writer) error
+	ImportProject(ctx context.Context, r io.Reader) (*ProjectBundle, error)
+}
+
+// ExportProject writes the project, its instances and their rendered assets to w as a tar.gz bundle.
+// Every instance volume needs a snapshot reference; the snapshots must be ready before the bundle is
+// restored. The manifests are informational, ImportProject renders the assets again.
+func (p *ProjectResourceManager) ExportProject(ctx context.Context, project *entity.Project, instances []entity.InstanceIF,
+	snapshots []VolumeSnapshotReference, w io.Writer) error {
+
+	if err := p.validateSnapshotReferences(ctx, instances, snapshots); err != nil {
+		logger.Errorf(ctx, "Project %s cannot be exported - %s", project.Name, err)
+		return err
+	}
+
+	settings, err := p.opts.projectSettings(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	var metadata = BundleMetadata{
+		FormatVersion: BUNDLE_FORMAT_VERSION,
+		ExportedAt:    p.opts.now(),
+		Project:       project,
+		Settings:      settings,
+		Volumes:       snapshots,
+	}
+
+	var manifests = make(map[string]*unstructured.Unstructured)
+
+	projectAssets, err := p.createProjectAssets(ctx, project, settings)
+	if err != nil {
+		return err
+	}
+	metadata.Manifests = append(metadata.Manifests, addManifests(manifests, project.Name, projectAssets)...)
+
+	for _, instance := range instances {
+		encoded, rendered := bundledInstance(instance)
+		data, err := bson.MarshalExtJSON(encoded, false, false)
+		if err != nil {
+			logger.Errorf(ctx, "Instance %s cannot be encoded - %s", instance.GetName(), err)
+			return errs.ErrInstanceDataFailed
+		}
+		metadata.Instances = append(metadata.Instances, data)
+
+		assets, err := p.CreateDeploymentResourceAssets(ctx, rendered)
+		if err != nil {
+			return err
+		}
+		metadata.Manifests = append(metadata.Manifests, addManifests(manifests, project.Name+"/"+instance.GetName(), assets)...)
+	}
+
+	if err = writeBundle(&metadata, manifests, w); err != nil {
+		logger.Errorf(ctx, "Bundle for project %s cannot be written - %s", project.Name, err)
+		return err
+	}
+
+	logger.Debugf(ctx, "Exported project %s with %d instances and %d manifests", project.Name, len(instances), len(metadata.Manifests))
+	return nil
+}
+
+// bundledInstance returns the instance encoded in a bundle and the instance its manifests are rendered
+// from. Bundles carry no credentials: zcash nodes are encoded without their rpcauth entries, and their
+// manifests leave out the credentials Secret.
+func bundledInstance(instance entity.InstanceIF) (entity.InstanceIF, entity.InstanceIF) {
+	zcash, ok := instance.(*ZcashInstance)
+	if !ok {
+		return instance, instance
+	}
+
+	var rendered = *zcash
+	rendered.ZcashNodeDetails = zcash.ZcashNodeDetails.clone()
+	rendered.credentials = rpcCredentials{}
+
+	var encoded = rendered
+	encoded.RPCAuth, encoded.RPCAuthExpires = nil, time.Time{}
+	return &encoded, &rendered
+}
+
+// ImportProject reads a bundle written by ExportProject. The project version must be available locally.
+// Instances are switched to restore their volumes from the referenced snapshots before their assets are
+// rendered, and zcash nodes get new credentials.
+func (p *ProjectResourceManager) ImportProject(ctx context.Context, r io.Reader) (*ProjectBundle, error) {
+
+	metadata, err := readBundleMetadata(r)
+	if err != nil {
+		logger.Errorf(ctx, "Bundle cannot be read - %s", err)
+		return nil, err
+	}
+
+	var errors ValidationErrors
+	if metadata.FormatVersion != BUNDLE_FORMAT_VERSION {
+		errors.Add("formatVersion", metadata.FormatVersion, "is not supported, expected %d", BUNDLE_FORMAT_VERSION)
+	}
+	if metadata.Project == nil {
+		errors.Add("project", nil, "is required")
+	} else if _, ok := p.GetProjectResources(metadata.Project.Version); !ok {
+		errors.Add("project.version", metadata.Project.Version, "is not an available project version")
+	}
+	validateMetadata(&errors, "settings", metadata.Settings.Labels, metadata.Settings.Annotations)
+	if err = errors.Err(); err != nil {
+		logger.Errorf(ctx, "Bundle cannot be imported - %s", err)
+		return nil, err
+	}
+
+	var bundle = ProjectBundle{Project: metadata.Project, Settings: metadata.Settings, Volumes: metadata.Volumes}
+
+	for _, data := range metadata.Instances {
+		instance, err := p.unmarshalExtJSONInstance(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		if zcash, ok := instance.(*ZcashInstance); ok {
+			if err = p.opts.newCredentials(zcash); err != nil {
+				logger.Errorf(ctx, "Credentials of zcash instance %s not generated - %s", zcash.Name, err)
+				return nil, errs.ErrInstanceResourceFailed
+			}
+		}
+		bundle.Instances = append(bundle.Instances, instance)
+	}
+
+	if err = p.validateSnapshotReferences(ctx, bundle.Instances, bundle.Volumes); err != nil {
+		logger.Errorf(ctx, "Bundle for project %s cannot be imported - %s", bundle.Project.Name, err)
+		return nil, err
+	}
+
+	projectAssets, err := p.createProjectAssets(ctx, bundle.Project, bundle.Settings)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Assets = append(bundle.Assets, projectAssets...)
+
+	for _, instance := range bundle.Instances {
+		if err = p.restoreFromSnapshots(ctx, instance, bundle.Volumes); err != nil {
+			return nil, err
+		}
+
+		assets, err := p.CreateDeploymentResourceAssets(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Assets = append(bundle.Assets, assets...)
+	}
+
+	logger.Debugf(ctx, "Imported project %s with %d instances and %d assets", bundle.Project.Name, len(bundle.Instances), len(bundle.Assets))
+	return &bundle, nil
+}
+
+// validateSnapshotReferences checks that every volume of every instance has exactly one snapshot.
+func (p *ProjectResourceManager) validateSnapshotReferences(ctx context.Context, instances []entity.InstanceIF, snapshots []VolumeSnapshotReference) error {
+	var references = make(map[string]int)
+	for _, snapshot := range snapshots {
+		references[snapshot.Instance+"/"+snapshot.Volume]++
+	}
+
+	var errors ValidationErrors
+	for _, instance := range instances {
+		volumes, err := p.instanceVolumes(ctx, instance)
+		if err != nil {
+			return err
+		}
+
+		for _, volume := range volumes {
+			field := fmt.Sprintf("volumes[%s/%s]", instance.GetName(), volume)
+			switch references[instance.GetName()+"/"+volume] {
+			case 0:
+				errors.Add(field, nil, "has no snapshot")
+			case 1:
+			default:
+				errors.Add(field, nil, "has more than one snapshot")
+			}
+		}
+	}
+
+	return errors.Err()
+}
+
+// restoreFromSnapshots records the snapshot each volume of the instance is restored from. The instance
+// data source is the snapshot of its first volume.
+func (p *ProjectResourceManager) restoreFromSnapshots(ctx context.Context, instance entity.InstanceIF, snapshots []VolumeSnapshotReference) error {
+	volumes, err := p.instanceVolumes(ctx, instance)
+	if err != nil || len(volumes) == 0 {
+		return err
+	}
+
+	base, details := baseInstance(instance), detailsOf(instance)
+	if base == nil || details == nil {
+		logger.Errorf(ctx, "Instance %s of type %s cannot be restored", instance.GetName(), instance.GetInstanceType())
+		return errs.ErrInstanceDataFailed
+	}
+
+	var sources = make(map[string]string, len(volumes))
+	for _, snapshot := range snapshots {
+		if snapshot.Instance == instance.GetName() {
+			sources[snapshot.Volume] = snapshot.Snapshot
+		}
+	}
+
+	details.VolumeSources = make(map[string]string, len(volumes))
+	for _, volume := range volumes {
+		if snapshot, ok := sources[volume]; ok {
+			details.VolumeSources[volume] = snapshot
+		}
+	}
+
+	if snapshot, ok := sources[volumes[0]]; ok {
+		base.DataSourceType = ztypes.SnapshotDataSource
+		base.DataSource = snapshot
+	}
+
+	return nil
+}
+
+func (p *ProjectResourceManager) instanceVolumes(ctx context.Context, instance entity.InstanceIF) ([]string, error) {
+	instResource, ok := p.GetInstanceResources(instance.GetInstanceType(), instance.GetVersion())
+	if !ok {
+		logger.Errorf(ctx, "Instance resource not available for %s", instance.GetVersion())
+		return nil, errs.ErrInstanceResourceFailed
+	}
+	return instResource.Volumes, nil
+}
+
+func (p *ProjectResourceManager) unmarshalExtJSONInstance(ctx context.Context, data json.RawMessage) (entity.InstanceIF, error) {
+	var document bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &document); err != nil {
+		logger.Errorf(ctx, "Bundle instance cannot be decoded - %s", err)
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		logger.Errorf(ctx, "Bundle instance cannot be decoded - %s", err)
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	return p.UnmarshalBSONInstance(ctx, raw)
+}
+
+// baseInstance returns the fields shared by every instance type.
+func baseInstance(instance entity.InstanceIF) *entity.Instance {
+	switch i := instance.(type) {
+	case *ZcashInstance:
+		return &i.Instance
+	case *entity.ZcashInstance:
+		return &i.Instance
+	case *LWDInstance:
+		return &i.Instance
+	case *entity.LWDInstance:
+		return &i.Instance
+	case *ZebraInstance:
+		return &i.Instance
+	}
+	return nil
+}
+
+// addManifests stores objects under manifests/<prefix>/ and returns their paths.
+func addManifests(manifests map[string]*unstructured.Unstructured, prefix string, objects []*unstructured.Unstructured) []string {
+	var paths = make([]string, 0, len(objects))
+	for index, object := range objects {
+		path := fmt.Sprintf("%s/%s/%02d-%s-%s.yaml", bundleManifestDir, prefix, index, strings.ToLower(object.GetKind()), object.GetName())
+		manifests[path] = object
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func writeBundle(metadata *BundleMetadata, manifests map[string]*unstructured.Unstructured, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = writeBundleFile(archive, bundleMetadataFile, data, metadata.ExportedAt); err != nil {
+		return err
+	}
+
+	for _, path := range metadata.Manifests {
+		manifest, err := helper.EncodeYAML(manifests[path])
+		if err != nil {
+			return err
+		}
+		if err = writeBundleFile(archive, path, []byte(manifest), metadata.ExportedAt); err != nil {
+			return err
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeBundleFile(archive *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}
+	if err := archive.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
+
+func readBundleMetadata(r io.Reader) (*BundleMetadata, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("bundle has no %s", bundleMetadataFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Name == bundleMetadataFile {
+			data, err := ioutil.ReadAll(archive)
+			if err != nil {
+				return nil, err
+			}
+
+			var metadata BundleMetadata
+			if err = json.Unmarshal(data, &metadata); err != nil {
+				return nil, err
+			}
+			return &metadata, nil
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package rsc
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/fake/test"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+	"time"
+)
+
+func Test_WriteBundle(t *testing.T) {
+	var manifests = make(map[string]*unstructured.Unstructured)
+	var metadata = BundleMetadata{
+		FormatVersion: BUNDLE_FORMAT_VERSION,
+		ExportedAt:    time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		Volumes:       []VolumeSnapshotReference{{Instance: "node", Volume: "zcash-data", Snapshot: "node-data-1"}},
+	}
+	metadata.Manifests = addManifests(manifests, "sample", []*unstructured.Unstructured{sampleAsset("ConfigMap", "conf", nil)})
+	assert.Equal(t, []string{"manifests/sample/00-configmap-conf.yaml"}, metadata.Manifests)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, writeBundle(&metadata, manifests, &buffer))
+
+	read, err := readBundleMetadata(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.Manifests, read.Manifests)
+	assert.Equal(t, metadata.Volumes, read.Volumes)
+	assert.True(t, metadata.ExportedAt.Equal(read.ExportedAt))
+
+	_, err = readBundleMetadata(bytes.NewBufferString("not a bundle"))
+	assert.Error(t, err)
+}
+
+func Test_ExportImportProject(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	project, _ := projManager.CreateProject(ctx, &request)
+
+	bundles := projManager.(ProjectBundleManager)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, bundles.ExportProject(ctx, project, nil, nil, &buffer))
+
+	bundle, err := bundles.ImportProject(ctx, &buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, project.Name, bundle.Project.Name)
+	assert.Empty(t, bundle.Instances)
+	assert.NotEmpty(t, bundle.Assets)
+}
+
+func Test_ExportImportProjectInstances(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	projManager, err := createProjectManager(ztypes.InstanceTypeZCASH, ztypes.InstanceTypeLWD)
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	project, _ := projManager.CreateProject(ctx, &request)
+
+	zcash, err := projManager.CreateInstance(ctx, project, ZcashNodeInstanceRequest{ZcashNodeInstanceRequest: object.ZcashNodeInstanceRequest{
+		InstanceRequest: object.InstanceRequest{Name: "node", Version: "v1", Description: "Zcash node", DataSourceType: ztypes.NoDataSource},
+	}})
+	assert.NoError(t, err)
+
+	lwd, err := projManager.CreateInstance(ctx, project, LWDInstanceRequest{LWDInstanceRequest: object.LWDInstanceRequest{
+		InstanceRequest: object.InstanceRequest{Name: "wallet", Version: "v1", Description: "Lightwalletd server", DataSourceType: ztypes.NoDataSource},
+		ZcashInstance:   "node",
+	}})
+	assert.NoError(t, err)
+
+	var snapshots = []VolumeSnapshotReference{
+		{Instance: "node", Volume: "zcash-data", Snapshot: "node-data-1"},
+		{Instance: "node", Volume: "zcash-params", Snapshot: "node-params-1"},
+		{Instance: "wallet", Volume: "lwd-data", Snapshot: "wallet-data-1"},
+	}
+
+	bundles := projManager.(ProjectBundleManager)
+
+	var buffer bytes.Buffer
+	assert.Error(t, bundles.ExportProject(ctx, project, []entity.InstanceIF{zcash, lwd}, snapshots[:2], &buffer))
+
+	buffer.Reset()
+	assert.NoError(t, bundles.ExportProject(ctx, project, []entity.InstanceIF{zcash, lwd}, snapshots, &buffer))
+
+	metadata, err := readBundleMetadata(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+	for _, data := range metadata.Instances {
+		assert.NotContains(t, string(data), "rpcPassword")
+		assert.NotContains(t, string(data), "rpcAuth")
+		assert.NotContains(t, string(data), zcash.(*ZcashInstance).credentials.password)
+	}
+	for _, manifest := range metadata.Manifests {
+		assert.NotContains(t, manifest, "-secret-")
+	}
+
+	bundle, err := bundles.ImportProject(ctx, &buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshots, bundle.Volumes)
+	assert.NotEmpty(t, bundle.Assets)
+	assert.Len(t, bundle.Instances, 2)
+
+	node, ok := bundle.Instances[0].(*ZcashInstance)
+	assert.True(t, ok)
+	assert.Equal(t, "node", node.Name)
+	assert.Equal(t, ztypes.SnapshotDataSource, node.DataSourceType)
+	assert.Equal(t, "node-data-1", node.DataSource)
+	assert.Equal(t, map[string]string{"zcash-data": "node-data-1", "zcash-params": "node-params-1"}, node.VolumeSources)
+	assert.Len(t, node.RPCAuth, 1)
+	assert.NotEqual(t, zcash.(*ZcashInstance).RPCAuth, node.RPCAuth)
+	assert.NotEmpty(t, node.credentials.password)
+
+	wallet, ok := bundle.Instances[1].(*LWDInstance)
+	assert.True(t, ok)
+	assert.Equal(t, "node", wallet.ZcashInstance)
+	assert.Equal(t, map[string]string{"lwd-data": "wallet-data-1"}, wallet.VolumeSources)
+}
@@ -0,0 +1,168 @@
+package rsc
+
+import (
+	"context"
+	"time"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ProjectCloneOptions controls how the instances of a project are copied.
+type ProjectCloneOptions struct {
+	// Snapshots holds a snapshot of every volume of the source instances, usually the latest ones. The
+	// snapshots must be readable from the namespace of the new project.
+	Snapshots []VolumeSnapshotReference
+
+	// InstanceNames renames source instances in the clone. Instances not listed keep their name.
+	InstanceNames map[string]string
+
+	// Settings are the settings of the new project. Callers record them with the returned project.
+	Settings ProjectSettings
+}
+
+// ProjectClone is a new project with copies of the instances of another one, and the assets that
+// create them.
+type ProjectClone struct {
+	Project   *entity.Project
+	Instances []entity.InstanceIF
+	Assets    []*unstructured.Unstructured
+}
+
+// ProjectCloneManager is implemented by project resource managers that can copy a project.
+type ProjectCloneManager interface {
+	CloneProject(ctx context.Context, source *entity.Project, instances []entity.InstanceIF,
+		request *object.ProjectRequest, opts ProjectCloneOptions) (*ProjectClone, error)
+}
+
+// CloneProject creates the project described by request with a copy of each source instance. The copies
+// restore their volumes from opts.Snapshots, and lightwalletd servers are pointed at the copies of their
+// zcash nodes. Nothing is changed on the source project.
+func (p *ProjectResourceManager) CloneProject(ctx context.Context, source *entity.Project, instances []entity.InstanceIF,
+	request *object.ProjectRequest, opts ProjectCloneOptions) (*ProjectClone, error) {
+
+	if err := p.ValidateProjectRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	var errors ValidationErrors
+	if request.Name == source.Name {
+		errors.Add("name", request.Name, "must differ from the project being cloned")
+	}
+	if request.Network != source.Network {
+		errors.Add("network", request.Network, "must be %s, the network of project %s", source.Network, source.Name)
+	}
+	for _, instance := range instances {
+		if zcash, ok := asZcashInstance(instance); ok && zcash.Miner && request.Network == ztypes.NetworkTypeMain && !opts.Settings.AllowMining {
+			errors.Add("settings.allowMining", opts.Settings.AllowMining, "is required to clone miner %s", zcash.Name)
+		}
+	}
+	validateMetadata(&errors, "settings", opts.Settings.Labels, opts.Settings.Annotations)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Project %s cannot be cloned - %s", source.Name, err)
+		return nil, err
+	}
+
+	if err := p.validateSnapshotReferences(ctx, instances, opts.Snapshots); err != nil {
+		logger.Errorf(ctx, "Project %s cannot be cloned - %s", source.Name, err)
+		return nil, err
+	}
+
+	project, err := p.CreateProject(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone = ProjectClone{Project: project}
+
+	assets, err := p.createProjectAssets(ctx, project, opts.Settings)
+	if err != nil {
+		return nil, err
+	}
+	clone.Assets = append(clone.Assets, assets...)
+
+	for _, instance := range instances {
+		copied, err := p.cloneInstance(ctx, project, instance, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		assets, err := p.CreateDeploymentResourceAssets(ctx, copied)
+		if err != nil {
+			return nil, err
+		}
+
+		clone.Instances = append(clone.Instances, copied)
+		clone.Assets = append(clone.Assets, assets...)
+	}
+
+	logger.Debugf(ctx, "Cloned project %s into %s with %d instances and %d assets", source.Name, project.Name, len(clone.Instances), len(clone.Assets))
+	return &clone, nil
+}
+
+// cloneInstance copies instance into project, restoring it from its snapshots and renaming it and the
+// instances it references according to opts.InstanceNames.
+func (p *ProjectResourceManager) cloneInstance(ctx context.Context, project *entity.Project, instance entity.InstanceIF, opts ProjectCloneOptions) (entity.InstanceIF, error) {
+	name := clonedName(opts.InstanceNames, instance.GetName())
+
+	var copied entity.InstanceIF
+	if source, ok := asZcashInstance(instance); ok {
+		zcash := *source
+		zcash.Peers = append([]string(nil), source.Peers...)
+		zcash.ZcashNodeDetails = source.ZcashNodeDetails.clone()
+		zcash.InstanceDetails = source.InstanceDetails.clone()
+		zcash.AutoPeering = opts.Settings.AutoPeering
+		zcash.DataVolume.Name = zcash.DataVolume.Volume + "-" + name
+		zcash.ParamsVolume.Name = zcash.ParamsVolume.Volume + "-" + name
+
+		// the copy gets credentials of its own
+		zcash.RPCAuth, zcash.RPCAuthExpires = nil, time.Time{}
+		if err := p.opts.newCredentials(&zcash); err != nil {
+			logger.Errorf(ctx, "Credentials of zcash instance %s not generated - %s", name, err)
+			return nil, errs.ErrInstanceResourceFailed
+		}
+		copied = &zcash
+	} else if source, ok := asLWDInstance(instance); ok {
+		lwd := *source
+		lwd.InstanceDetails = source.InstanceDetails.clone()
+		lwd.ZcashInstance = clonedName(opts.InstanceNames, source.ZcashInstance)
+		lwd.DataVolume.Name = lwd.DataVolume.Volume + "-" + name
+		copied = &lwd
+	} else if source, ok := instance.(*ZebraInstance); ok {
+		zebra := *source
+		zebra.Peers = append([]string(nil), source.Peers...)
+		zebra.InstanceDetails = source.InstanceDetails.clone()
+		zebra.DataVolume.Name = zebra.DataVolume.Volume + "-" + name
+		copied = &zebra
+	} else {
+		logger.Errorf(ctx, "Instance %s of type %s cannot be cloned", instance.GetName(), instance.GetInstanceType())
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	// the snapshots are recorded under the source instance name
+	if err := p.restoreFromSnapshots(ctx, copied, opts.Snapshots); err != nil {
+		return nil, err
+	}
+
+	base := baseInstance(copied)
+	base.Project = project.GetName()
+	base.Name = name
+	base.Owner = project.GetOwner()
+	base.Status = "New"
+	base.Timestamp = p.opts.now()
+	base.Action = "created"
+	base.ActionTime = p.opts.now()
+
+	return copied, nil
+}
+
+func clonedName(names map[string]string, name string) string {
+	if cloned, ok := names[name]; ok {
+		return cloned
+	}
+	return name
+}
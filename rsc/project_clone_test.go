@@ -0,0 +1,93 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/fake/test"
+	"testing"
+)
+
+func Test_ClonedName(t *testing.T) {
+	names := map[string]string{"node": "node-copy"}
+	assert.Equal(t, "node-copy", clonedName(names, "node"))
+	assert.Equal(t, "wallet", clonedName(names, "wallet"))
+	assert.Equal(t, "node", clonedName(nil, "node"))
+}
+
+func Test_CloneProject(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	source, _ := projManager.CreateProject(ctx, &request)
+
+	cloner := projManager.(ProjectCloneManager)
+
+	_, err = cloner.CloneProject(ctx, source, nil, &request, ProjectCloneOptions{})
+	assert.Error(t, err)
+
+	request.Network = ztypes.NetworkTypeMain
+	request.Name = "sample-copy"
+	_, err = cloner.CloneProject(ctx, source, nil, &request, ProjectCloneOptions{})
+	assert.Error(t, err)
+
+	request.Network = ztypes.NetworkTypeTest
+	clone, err := cloner.CloneProject(ctx, source, []entity.InstanceIF{}, &request, ProjectCloneOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "sample-copy", clone.Project.Name)
+	assert.Empty(t, clone.Instances)
+	assert.NotEmpty(t, clone.Assets)
+}
+
+func Test_CloneProjectCredentials(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	projManager, err := createProjectManager(ztypes.InstanceTypeZCASH)
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	source, _ := projManager.CreateProject(ctx, &request)
+
+	instance, err := projManager.CreateInstance(ctx, source, ZcashNodeInstanceRequest{ZcashNodeInstanceRequest: object.ZcashNodeInstanceRequest{
+		InstanceRequest: object.InstanceRequest{Name: "node", Version: "v1", Description: "Zcash node", DataSourceType: ztypes.NoDataSource},
+		Peers:           []string{"peer1.example.com"},
+	}})
+	assert.NoError(t, err)
+	node := instance.(*ZcashInstance)
+
+	var snapshots = []VolumeSnapshotReference{
+		{Instance: "node", Volume: "zcash-data", Snapshot: "node-data-1"},
+		{Instance: "node", Volume: "zcash-params", Snapshot: "node-params-1"},
+	}
+
+	request.Name = "sample-copy"
+	clone, err := projManager.(ProjectCloneManager).CloneProject(ctx, source, []entity.InstanceIF{node}, &request, ProjectCloneOptions{Snapshots: snapshots})
+	assert.NoError(t, err)
+	assert.Len(t, clone.Instances, 1)
+
+	copied := clone.Instances[0].(*ZcashInstance)
+	assert.Equal(t, "sample-copy", copied.Project)
+	assert.NotEmpty(t, copied.RPCAuth)
+	assert.True(t, copied.RPCAuthExpires.IsZero())
+	assert.NotEqual(t, node.credentials, copied.credentials)
+	for _, entry := range copied.RPCAuth {
+		assert.NotContains(t, node.RPCAuth, entry)
+	}
+
+	copied.Peers[0] = "peer2.example.com"
+	copied.RPCAuth[0] = "changed"
+	assert.Equal(t, "peer1.example.com", node.Peers[0])
+	assert.NotEqual(t, "changed", node.RPCAuth[0])
+}
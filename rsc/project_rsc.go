@@ -2,12 +2,11 @@ package rsc
 
 import (
 	"context"
-	"encoding/json"
-	"github.com/zbitech/common/pkg/model/k8s"
 	"github.com/zbitech/common/pkg/utils"
 	"github.com/zbitech/mgr/internal/helper"
+	"github.com/zbitech/mgr/internal/ingress"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"time"
+	"sync"
 
 	"github.com/zbitech/common/interfaces"
 	"github.com/zbitech/common/pkg/errs"
@@ -15,22 +14,42 @@ import (
 	"github.com/zbitech/common/pkg/model/entity"
 	"github.com/zbitech/common/pkg/model/object"
 	"github.com/zbitech/common/pkg/model/spec"
-	"github.com/zbitech/common/pkg/vars"
 
 	"github.com/zbitech/common/pkg/logger"
 	"github.com/zbitech/common/pkg/model/ztypes"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+var (
+	PROJECT_TEMPLATES = TemplateContract{
+		projectOperation:        {"NAMESPACE", "SERVICE_ACCOUNT", "ROLE", "ROLE_BINDING", "RESOURCE_QUOTA", "LIMIT_RANGE", "NETWORK_POLICY"},
+		authzOperation:          {"AUTHZ_DEPLOYMENT", "AUTHZ_SERVICE"},
+		projectIngressOperation: {"INGRESS", "INGRESS_INCLUDE", "CONTROLLER_INGRESS"},
+	}
+)
+
 type ProjectResourceManager struct {
+	mu            sync.RWMutex
 	projectConfig *config.ProjectResourceConfig
+	templates     map[string]*FileTemplate
 	instances     map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF
+	opts          Options
 }
 
 func NewProjectResourceManager(projectConfig *config.ProjectResourceConfig,
-	instanceManagers map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF) (interfaces.ProjectResourceManagerIF, error) {
+	instanceManagers map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF, opts Options) (interfaces.ProjectResourceManagerIF, error) {
+	versions, templates, err := loadVersions(opts.Assets, projectConfig.Versions, object.NO_FUNCS)
+	if err != nil {
+		return nil, err
+	}
+
+	var resourceConfig = *projectConfig
+	resourceConfig.Versions = versions
+
 	var projectManager = &ProjectResourceManager{
-		projectConfig: projectConfig,
+		projectConfig: &resourceConfig,
+		templates:     templates,
+		opts:          opts,
 		instances:     make(map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF),
 	}
 
@@ -38,21 +57,110 @@ func NewProjectResourceManager(projectConfig *config.ProjectResourceConfig,
 		projectManager.instances[iType] = iManager
 	}
 
-	for _, cfg := range projectManager.projectConfig.Versions {
-		if err := cfg.Init(vars.ASSET_PATH_DIRECTORY, object.NO_FUNCS); err != nil {
-			return nil, err
+	return projectManager, nil
+}
+
+func (p *ProjectResourceManager) config() *config.ProjectResourceConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.projectConfig
+}
+
+func (p *ProjectResourceManager) fileTemplate(version string) *FileTemplate {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.templates[version]
+}
+
+func (p *ProjectResourceManager) PrepareReload(ctx context.Context, cfg *config.ProjectResourceConfig) (func(), error) {
+	versions, templates, err := loadVersions(p.opts.Assets, cfg.Versions, object.NO_FUNCS)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.verification().verify(versions, templates).Err(); err != nil {
+		return nil, err
+	}
+
+	var projectConfig = *cfg
+	projectConfig.Versions = versions
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.projectConfig = &projectConfig
+		p.templates = templates
+	}, nil
+}
+
+func (p *ProjectResourceManager) VerifyTemplates(ctx context.Context) TemplateReport {
+	p.mu.RLock()
+	versions, templates := p.projectConfig.Versions, p.templates
+	p.mu.RUnlock()
+
+	return p.verification().verify(versions, templates)
+}
+
+func (p *ProjectResourceManager) verification() templateVerification {
+	var contract = PROJECT_TEMPLATES
+	if !p.opts.Features.AccessAuthorizationEnabled {
+		contract = TemplateContract{}
+		for operation, keys := range PROJECT_TEMPLATES {
+			if operation != authzOperation {
+				contract[operation] = keys
+			}
 		}
 	}
-	return projectManager, nil
+
+	return templateVerification{
+		manager:   "project",
+		contract:  contract,
+		fragments: map[string]bool{"INGRESS_INCLUDE": true, "CONTROLLER_INGRESS": true},
+		sample:    p.sampleSpec,
+	}
+}
+
+func (p *ProjectResourceManager) sampleSpec(operation, version string, cfg *config.VersionedResourceConfig) interface{} {
+	project := sampleProject(version)
+
+	pSpec := projectSpec{Quota: p.opts.Quotas.QuotaFor(project.TeamId)}
+	pSpec.Namespace = project.GetNamespace()
+	pSpec.ServiceAccountName = helper.CreateServiceAccountName(project.Name)
+	pSpec.Labels = helper.CreateProjectLabels(project)
+	pSpec.DomainName = p.opts.Policy.Domain
+	pSpec.DomainSecret = p.opts.Policy.CertName
+	if operation == authzOperation {
+		// a missing image is reported when the templates are used; the sample only needs to render
+		pSpec.Authz, _ = p.opts.authzSpec(context.Background(), cfg)
+	}
+	return pSpec
 }
 
 func (p *ProjectResourceManager) GetProjectResources(version string) (*config.VersionedResourceConfig, bool) {
-	resource, ok := p.projectConfig.Versions[version]
+	resource, ok := p.config().Versions[version]
 	return resource, ok
 }
 
+// ValidateProjectRequest checks the request against the naming rules of Kubernetes and the configured
+// project versions. All problems found are returned together as ValidationErrors.
 func (p *ProjectResourceManager) ValidateProjectRequest(ctx context.Context, request *object.ProjectRequest) error {
 
+	var errors ValidationErrors
+
+	validateDNS1123Label(&errors, "name", request.Name, MaxProjectNameLength)
+	validateNetwork(&errors, "network", request.Network)
+
+	if len(request.Version) == 0 {
+		errors.Add("version", request.Version, "is required")
+	} else if _, ok := p.GetProjectResources(request.Version); !ok {
+		errors.Add("version", request.Version, "is not an available project version")
+	}
+
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Project request %s is invalid - %s", request.Name, err)
+		return err
+	}
+
 	return nil
 }
 
@@ -69,7 +177,7 @@ func (p *ProjectResourceManager) CreateProject(ctx context.Context, req *object.
 	project.Owner = owner
 	project.Status = "New"
 	project.Action = "created"
-	project.Timestamp = time.Now()
+	project.Timestamp = p.opts.now()
 
 	return &project, nil
 }
@@ -79,12 +187,22 @@ func (p *ProjectResourceManager) UpdateProject(ctx context.Context, project *ent
 	project.TeamId = request.Team
 	project.Description = request.Description
 	project.Action = "updated"
-	project.ActionTime = time.Now()
+	project.ActionTime = p.opts.now()
 
 	return nil
 }
 
 func (p *ProjectResourceManager) CreateProjectAssets(ctx context.Context, project *entity.Project) ([]*unstructured.Unstructured, error) {
+	settings, err := p.opts.projectSettings(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.createProjectAssets(ctx, project, settings)
+}
+
+// createProjectAssets renders the assets of project with settings, which callers may not have recorded yet.
+func (p *ProjectResourceManager) createProjectAssets(ctx context.Context, project *entity.Project, settings ProjectSettings) ([]*unstructured.Unstructured, error) {
 
 	projResources, ok := p.GetProjectResources(project.Version)
 	if !ok {
@@ -92,10 +210,10 @@ func (p *ProjectResourceManager) CreateProjectAssets(ctx context.Context, projec
 		return nil, errs.ErrProjectResourceFailed
 	}
 
-	pSpec := spec.ProjectSpec{}
+	pSpec := projectSpec{Quota: p.opts.Quotas.QuotaFor(project.TeamId)}
 	//	pSpec.Project = *project
 	pSpec.Namespace = project.GetNamespace()
-	//	pSpec.ServiceAccountName = vars.AppConfig.Policy.ServiceAccount
+	pSpec.ServiceAccountName = helper.CreateServiceAccountName(project.Name)
 	//	pSpec.Domain = vars.AppConfig.Policy.Domain
 	//	pSpec.CertName = vars.AppConfig.Policy.CertName
 
@@ -106,14 +224,16 @@ func (p *ProjectResourceManager) CreateProjectAssets(ctx context.Context, projec
 
 	logger.Debugf(ctx, "Created project spec - %s", utils.MarshalObject(pSpec))
 
-	fileTemplate := projResources.GetFileTemplate()
+	fileTemplate := p.fileTemplate(project.Version)
 
-	var templates []string
-	if vars.AppConfig.Features.AccessAuthorizationEnabled {
-		//		templates = []string{"NAMESPACE", "SERVICE", "AUTHZ_SERVICE", "AUTHZ_EXTENSION"}
-		templates = []string{"NAMESPACE", "SERVICE"}
-	} else {
-		templates = []string{"NAMESPACE", "SERVICE"}
+	var templates = PROJECT_TEMPLATES[projectOperation]
+	if p.opts.Features.AccessAuthorizationEnabled {
+		authz, err := p.opts.authzSpec(ctx, projResources)
+		if err != nil {
+			return nil, err
+		}
+		pSpec.Authz = authz
+		templates = append(append([]string{}, templates...), PROJECT_TEMPLATES[authzOperation]...)
 	}
 
 	specArr, err := fileTemplate.ExecuteTemplates(templates, pSpec)
@@ -124,27 +244,35 @@ func (p *ProjectResourceManager) CreateProjectAssets(ctx context.Context, projec
 
 	logger.Debugf(ctx, "Generated spec details - %s", specArr)
 
-	return helper.CreateYAMLObjects(specArr)
+	objects, err := helper.CreateYAMLObjects(specArr)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyMetadata(objects, settings.Labels, settings.Annotations), nil
 }
 
 func (p *ProjectResourceManager) CreateProjectIngressAsset(ctx context.Context, appIngress *unstructured.Unstructured, project *entity.Project, action ztypes.EventAction) ([]*unstructured.Unstructured, error) {
-	projResources, ok := p.GetProjectResources(project.Version)
+	_, ok := p.GetProjectResources(project.Version)
 	if !ok {
 		logger.Errorf(ctx, "Project resource not available for %s", project.Version)
 		return nil, errs.ErrProjectResourceFailed
 	}
 
+	settings, err := p.opts.projectSettings(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
 	pSpec := spec.ProjectSpec{}
 	pSpec.Namespace = project.GetNamespace()
 	pSpec.Labels = helper.CreateProjectLabels(project)
-	//pSpec.Data = utils.MarshalObject(project)
-	//pSpec.DomainName = vars.AppConfig.Policy.Domain
-	//pSpec.DomainSecret = vars.AppConfig.Policy.CertName
-	//pSpec.Envoy = helper.CreateEnvoySpec()
+	pSpec.DomainName = p.opts.Policy.Domain
+	pSpec.DomainSecret = p.opts.Policy.CertName
 
 	logger.Debugf(ctx, "Created project spec - %s", utils.MarshalObject(pSpec))
 
-	fileTemplate := projResources.GetFileTemplate()
+	fileTemplate := p.fileTemplate(project.Version)
 
 	specObj, err := fileTemplate.ExecuteTemplates([]string{"INGRESS", "INGRESS_INCLUDE"}, pSpec)
 	if err != nil {
@@ -160,44 +288,14 @@ func (p *ProjectResourceManager) CreateProjectIngressAsset(ctx context.Context,
 		return nil, errs.ErrIngressResourceFailed
 	}
 
-	var includeObj k8s.IngressInclude
-	if err = json.Unmarshal([]byte(specObj[1]), &includeObj); err != nil {
-		logger.Errorf(ctx, "Controller app template failed - %s", err)
-		return nil, errs.ErrIngressResourceFailed
-	}
-
-	//TODO - handle appIngress == nil - Return error?
-
-	utils.RemoveResourceField(appIngress, "metadata.managedFields")
-	utils.RemoveResourceField(appIngress, "spec.status")
-
-	var includes []k8s.IngressInclude
-	includeData := utils.GetResourceField(appIngress, "spec.includes")
-	if includeData == nil {
-		includes = make([]k8s.IngressInclude, 0)
-	} else if err = json.Unmarshal([]byte(includeData.(string)), &includes); err != nil {
-		logger.Errorf(ctx, "Error unmarshaling ingress routes - %s", err)
-	}
-
-	var updated = false
-	for index, include := range includes {
-		if include.Namespace == includeObj.Namespace {
-			if action == ztypes.EventActionDelete {
-				includes = append(includes[:index], includes[index+1:]...)
-			} else {
-				includes = append(includes[:index], includeObj)
-				includes = append(includes, includes[index+1:]...)
-			}
-			updated = true
-		}
-	}
-
-	if !updated && action != "remove" {
-		includes = append(includes, includeObj)
+	appIngress, err = reconcileIngress(ctx, appIngress, ingress.Include, specObj[1], action, func() (string, error) {
+		return fileTemplate.ExecuteTemplate("CONTROLLER_INGRESS", pSpec)
+	})
+	if err != nil {
+		return nil, err
 	}
-	logger.Debugf(ctx, "Ingress includes - %s", utils.MarshalObject(includes))
-	utils.SetResourceField(appIngress, "spec.includes", includes)
 
+	applyMetadata([]*unstructured.Unstructured{&ingressObj}, settings.Labels, settings.Annotations)
 	return []*unstructured.Unstructured{appIngress, &ingressObj}, nil
 }
 
@@ -225,7 +323,22 @@ func (p *ProjectResourceManager) CreateInstance(ctx context.Context, project *en
 		return nil, errs.ErrInstanceDataFailed
 	}
 
-	return dataManager.CreateInstance(ctx, project, req)
+	if err := validateInstanceMetadata(ctx, req); err != nil {
+		return nil, err
+	}
+
+	settings, err := p.opts.projectSettings(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := dataManager.CreateInstance(ctx, project, req)
+	if err != nil {
+		return nil, err
+	}
+
+	setInstanceMetadata(settings, instance, req)
+	return instance, nil
 }
 
 func (p *ProjectResourceManager) UpdateInstance(ctx context.Context, project *entity.Project, instance entity.InstanceIF, request object.InstanceRequestIF) error {
@@ -234,7 +347,21 @@ func (p *ProjectResourceManager) UpdateInstance(ctx context.Context, project *en
 		return errs.ErrInstanceDataFailed
 	}
 
-	return resourceManager.UpdateInstance(ctx, project, instance, request)
+	if err := validateInstanceMetadata(ctx, request); err != nil {
+		return err
+	}
+
+	settings, err := p.opts.projectSettings(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	if err := resourceManager.UpdateInstance(ctx, project, instance, request); err != nil {
+		return err
+	}
+
+	setInstanceMetadata(settings, instance, request)
+	return nil
 }
 
 func (p *ProjectResourceManager) CreateDeploymentResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
@@ -282,13 +409,62 @@ func (p *ProjectResourceManager) CreateSnapshotScheduleAssets(ctx context.Contex
 	return resourceManager.CreateSnapshotScheduleAssets(ctx, instance, volume, schedule)
 }
 
+// CreateRotationAssets rotates the credentials of instance. The assets also roll over the instances that
+// depend on it, such as the lightwalletd servers of a zcash node. The new credentials are only recorded
+// on instance once the assets of every dependent are rendered.
 func (p *ProjectResourceManager) CreateRotationAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
 	resourceManager, ok := p.instances[instance.GetInstanceType()]
 	if !ok {
 		return nil, errs.ErrInstanceDataFailed
 	}
 
-	return resourceManager.CreateRotationAssets(ctx, instance)
+	var objects []*unstructured.Unstructured
+	var commit = func() {}
+	var err error
+	if rotator, ok := resourceManager.(credentialRotator); ok {
+		objects, commit, err = rotator.prepareRotation(ctx, instance)
+	} else {
+		objects, err = resourceManager.CreateRotationAssets(ctx, instance)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dependents, err := p.dependentInstances(ctx, instance)
+	if err != nil {
+		logger.Errorf(ctx, "Instances depending on %s not available - %s", instance.GetName(), err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	for _, dependent := range dependents {
+		dependentManager, ok := p.instances[dependent.GetInstanceType()]
+		if !ok {
+			return nil, errs.ErrInstanceDataFailed
+		}
+
+		assets, err := dependentManager.CreateRotationAssets(ctx, dependent)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, assets...)
+	}
+
+	commit()
+	return objects, nil
+}
+
+// CreateCredentialExpiryAssets drops the replaced credentials of instance that are no longer accepted.
+// Instances without credentials have no assets.
+func (p *ProjectResourceManager) CreateCredentialExpiryAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+	resourceManager, ok := p.instances[instance.GetInstanceType()]
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	if expiry, ok := resourceManager.(CredentialExpiryManager); ok {
+		return expiry.CreateCredentialExpiryAssets(ctx, instance)
+	}
+	return nil, nil
 }
 
 func (p *ProjectResourceManager) UnmarshalBSONInstance(ctx context.Context, data bson.Raw) (entity.InstanceIF, error) {
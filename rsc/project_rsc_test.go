@@ -8,6 +8,7 @@ import (
 	"github.com/zbitech/common/pkg/model/object"
 	"github.com/zbitech/common/pkg/model/ztypes"
 	"github.com/zbitech/common/pkg/vars"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"testing"
 )
 
@@ -18,11 +19,11 @@ func Test_NewProjectResourceManager(t *testing.T) {
 	var projManager interfaces.ProjectResourceManagerIF
 	var err error
 
-	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil)
+	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
 	assert.NoErrorf(t, err, "Failed to create project resource manager")
 	assert.NotNilf(t, projManager, "Failed to create project resource manager")
 
-	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF{})
+	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF{}, testOptions())
 	assert.NoErrorf(t, err, "Failed to create project resource manager")
 	assert.NotNilf(t, projManager, "Failed to create project resource manager")
 }
@@ -34,7 +35,7 @@ func Test_GetProjectResources(t *testing.T) {
 	var projManager interfaces.ProjectResourceManagerIF
 	var err error
 
-	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil)
+	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
 	assert.NoErrorf(t, err, "Failed to create project resource manager")
 
 	_, ok := projManager.GetProjectResources("v1")
@@ -48,7 +49,7 @@ func Test_CreateProject(t *testing.T) {
 	var projManager interfaces.ProjectResourceManagerIF
 	var err error
 
-	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil)
+	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
 	assert.NoErrorf(t, err, "Failed to create project resource manager")
 
 	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
@@ -57,6 +58,30 @@ func Test_CreateProject(t *testing.T) {
 	assert.NotNilf(t, project, "Failed to create project")
 }
 
+func Test_ValidateProjectRequest(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	assert.NoError(t, projManager.ValidateProjectRequest(ctx, &request))
+
+	request = object.ProjectRequest{Name: "Sample_Project", Version: "v0", Network: "devnet", Team: "team1"}
+	err = projManager.ValidateProjectRequest(ctx, &request)
+	assert.Error(t, err)
+
+	fieldErrors, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+
+	var fields = make(map[string]bool)
+	for _, fieldError := range fieldErrors {
+		fields[fieldError.Field] = true
+	}
+	assert.Equal(t, map[string]bool{"name": true, "network": true, "version": true}, fields)
+}
+
 func Test_CreateProjectAsserts(t *testing.T) {
 	ctx := context.Background()
 	factory.InitProjectResourceConfig(ctx)
@@ -64,7 +89,7 @@ func Test_CreateProjectAsserts(t *testing.T) {
 	var projManager interfaces.ProjectResourceManagerIF
 	var err error
 
-	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil)
+	projManager, err = NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
 	assert.NoErrorf(t, err, "Failed to create project resource manager")
 
 	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
@@ -73,7 +98,36 @@ func Test_CreateProjectAsserts(t *testing.T) {
 
 	objects, err := projManager.CreateProjectAssets(ctx, project)
 	assert.NoErrorf(t, err, "Failed to generate project assets")
-	assert.Lenf(t, objects, 1, "Failed to generate 1 resource")
+	assert.Lenf(t, objects, 5, "Failed to generate 5 resources")
+}
+
+func Test_CreateProjectAuthzAssets(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	opts := testOptions()
+	opts.Features.AccessAuthorizationEnabled = true
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, nil, opts)
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	project, _ := projManager.CreateProject(ctx, &request)
+
+	objects, err := projManager.CreateProjectAssets(ctx, project)
+	assert.NoErrorf(t, err, "Failed to generate project assets")
+
+	// Only the authorization server mounts the token of the project service account.
+	var automount = make(map[string]bool)
+	for _, object := range objects {
+		switch object.GetKind() {
+		case "ServiceAccount":
+			automount[object.GetKind()], _, _ = unstructured.NestedBool(object.Object, "automountServiceAccountToken")
+		case "Deployment":
+			automount[object.GetKind()], _, _ = unstructured.NestedBool(object.Object, "spec", "template", "spec", "automountServiceAccountToken")
+		}
+	}
+	assert.Equal(t, map[string]bool{"ServiceAccount": false, "Deployment": true}, automount)
 }
 
 func Test_CreateProjectSpec(t *testing.T) {
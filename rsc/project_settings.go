@@ -0,0 +1,47 @@
+package rsc
+
+import (
+	"context"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/entity"
+)
+
+// ProjectSettings are the settings of a project that entity.Project does not hold. Callers keep them with
+// their projects and provide them through Options.ProjectSettings.
+type ProjectSettings struct {
+	Metadata `bson:",inline"`
+
+	// AutoPeering peers each zcash node of the project with the other zcash nodes of the project.
+	AutoPeering bool `json:"autoPeering,omitempty" bson:"autoPeering,omitempty"`
+
+	// AllowMining lets zcash nodes of mainnet projects run miners.
+	AllowMining bool `json:"allowMining,omitempty" bson:"allowMining,omitempty"`
+}
+
+// ValidateProjectSettings checks the settings of project before they are recorded.
+func ValidateProjectSettings(ctx context.Context, project string, settings ProjectSettings) error {
+	var errors ValidationErrors
+	validateMetadata(&errors, "", settings.Labels, settings.Annotations)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Settings of project %s are invalid - %s", project, err)
+		return err
+	}
+	return nil
+}
+
+// projectSettings returns the settings of project. Projects have no settings when Options.ProjectSettings
+// is nil.
+func (o Options) projectSettings(ctx context.Context, project *entity.Project) (ProjectSettings, error) {
+	if o.ProjectSettings == nil {
+		return ProjectSettings{}, nil
+	}
+
+	settings, err := o.ProjectSettings(ctx, project.GetName())
+	if err != nil {
+		logger.Errorf(ctx, "Settings of project %s not available - %s", project.GetName(), err)
+		return ProjectSettings{}, errs.ErrProjectResourceFailed
+	}
+	return settings, nil
+}
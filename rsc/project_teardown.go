@@ -0,0 +1,260 @@
+package rsc
+
+import (
+	"context"
+	"sort"
+
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/mgr/internal/helper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// DELETION_ORDER ranks object kinds so that traffic is cut before workloads are stopped and
+	// workloads are stopped before the configuration and storage they use are removed.
+	DELETION_ORDER = map[string]int{
+		"HTTPProxy":             0,
+		"NetworkPolicy":         1,
+		"SnapshotSchedule":      2,
+		"CronJob":               3,
+		"Job":                   3,
+		"Deployment":            4,
+		"StatefulSet":           4,
+		"Service":               5,
+		"ConfigMap":             6,
+		"Secret":                6,
+		"PersistentVolumeClaim": 7,
+		"RoleBinding":           8,
+		"Role":                  9,
+		"ServiceAccount":        9,
+	}
+
+	// INSTANCE_DELETION_KINDS maps the kinds of object instance templates create, other than volume claims,
+	// to their API version.
+	INSTANCE_DELETION_KINDS = map[string]string{
+		"HTTPProxy":        "projectcontour.io/v1",
+		"NetworkPolicy":    "networking.k8s.io/v1",
+		"SnapshotSchedule": "snapscheduler.backube/v1",
+		"Deployment":       "apps/v1",
+		"Service":          "v1",
+		"ConfigMap":        "v1",
+		"Secret":           "v1",
+	}
+)
+
+// ProjectDeletionOptions controls what CreateProjectDeletionAssets retains.
+type ProjectDeletionOptions struct {
+	// KeepVolumes leaves the persistent volume claims of every instance in place.
+	KeepVolumes bool
+
+	// FinalSnapshots takes a snapshot of every instance volume before anything is deleted.
+	FinalSnapshots bool
+}
+
+// ProjectDeletionAssets lists what to do to remove a project, in the order it must be done.
+type ProjectDeletionAssets struct {
+	// Snapshots are created, and must be ready, before any deletion starts.
+	Snapshots []*unstructured.Unstructured
+
+	// AppIngress is the app ingress without the project include. It is applied before the deletions.
+	AppIngress *unstructured.Unstructured
+
+	// Deletions are references (apiVersion, kind, namespace and name) to delete in order. References
+	// without a name delete every object of their kind in the namespace that has their labels.
+	Deletions []*unstructured.Unstructured
+}
+
+// ProjectTeardownManager is implemented by project resource managers that can generate the assets
+// to remove a project.
+type ProjectTeardownManager interface {
+	CreateProjectDeletionAssets(ctx context.Context, project *entity.Project, instances []entity.InstanceIF,
+		appIngress *unstructured.Unstructured, opts ProjectDeletionOptions) (*ProjectDeletionAssets, error)
+}
+
+// CreateProjectDeletionAssets returns, in dependency order, everything to delete for the project and its
+// instances. The namespace is deleted last, and only when nothing in it is retained.
+func (p *ProjectResourceManager) CreateProjectDeletionAssets(ctx context.Context, project *entity.Project, instances []entity.InstanceIF,
+	appIngress *unstructured.Unstructured, opts ProjectDeletionOptions) (*ProjectDeletionAssets, error) {
+
+	var assets ProjectDeletionAssets
+
+	if opts.FinalSnapshots {
+		for _, instance := range instances {
+			snapshots, err := p.createFinalSnapshotAssets(ctx, instance)
+			if err != nil {
+				return nil, err
+			}
+			assets.Snapshots = append(assets.Snapshots, snapshots...)
+		}
+	}
+
+	var projectIngress *unstructured.Unstructured
+	if appIngress != nil {
+		objects, err := p.CreateProjectIngressAsset(ctx, appIngress, project, ztypes.EventActionDelete)
+		if err != nil {
+			return nil, err
+		}
+		assets.AppIngress, projectIngress = objects[0], objects[1]
+	}
+
+	for _, instance := range instances {
+		objects, err := p.createInstanceDeletionAssets(ctx, instance, opts)
+		if err != nil {
+			return nil, err
+		}
+		assets.Deletions = append(assets.Deletions, objects...)
+	}
+
+	projectObjects, err := p.CreateProjectAssets(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespace *unstructured.Unstructured
+	var objects []*unstructured.Unstructured
+	if projectIngress != nil {
+		objects = append(objects, projectIngress)
+	}
+	for _, object := range projectObjects {
+		if object.GetKind() == "Namespace" {
+			namespace = object
+		} else {
+			objects = append(objects, object)
+		}
+	}
+	assets.Deletions = append(assets.Deletions, orderForDeletion(objects)...)
+
+	if namespace != nil && !opts.KeepVolumes && !opts.FinalSnapshots {
+		assets.Deletions = append(assets.Deletions, deletionReference(namespace))
+	}
+
+	logger.Debugf(ctx, "Generated %d deletions and %d snapshots for project %s", len(assets.Deletions), len(assets.Snapshots), project.Name)
+	return &assets, nil
+}
+
+// createInstanceDeletionAssets derives the references to delete for an instance from its labels and volumes,
+// without rendering its templates. Volume claims are referenced by name, everything else by the labels of
+// the instance, which also selects the snapshot schedules of every schedule type.
+func (p *ProjectResourceManager) createInstanceDeletionAssets(ctx context.Context, instance entity.InstanceIF, opts ProjectDeletionOptions) ([]*unstructured.Unstructured, error) {
+	if _, ok := p.instances[instance.GetInstanceType()]; !ok {
+		logger.Errorf(ctx, "No resource manager for instance %s of type %s", instance.GetName(), instance.GetInstanceType())
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	// objects keep the labels of the version they were rendered with, so the version does not select them
+	var labels = helper.CreateInstanceLabels(instance)
+	delete(labels, "version")
+
+	var objects []*unstructured.Unstructured
+	for _, kind := range sortedKeys(INSTANCE_DELETION_KINDS) {
+		objects = append(objects, selectorReference(INSTANCE_DELETION_KINDS[kind], kind, instance.GetNamespace(), labels))
+	}
+
+	if !opts.KeepVolumes {
+		volumes, ok := instanceDataVolumes(instance)
+		if !ok {
+			logger.Errorf(ctx, "Volumes of instance %s of type %s are unknown", instance.GetName(), instance.GetInstanceType())
+			return nil, errs.ErrInstanceDataFailed
+		}
+
+		for _, volume := range volumes {
+			var reference unstructured.Unstructured
+			reference.SetAPIVersion("v1")
+			reference.SetKind("PersistentVolumeClaim")
+			reference.SetNamespace(instance.GetNamespace())
+			reference.SetName(volume.Name)
+			objects = append(objects, &reference)
+		}
+	}
+
+	return orderForDeletion(objects), nil
+}
+
+func (p *ProjectResourceManager) createFinalSnapshotAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+	resourceManager, ok := p.instances[instance.GetInstanceType()]
+	if !ok {
+		logger.Errorf(ctx, "No resource manager for instance %s of type %s", instance.GetName(), instance.GetInstanceType())
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := resourceManager.GetInstanceResources(instance.GetVersion())
+	if !ok {
+		logger.Errorf(ctx, "Instance resource not available for %s", instance.GetVersion())
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	var snapshots []*unstructured.Unstructured
+	for _, volume := range instResource.Volumes {
+		objects, err := resourceManager.CreateSnapshotAssets(ctx, instance, volume)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, objects...)
+	}
+
+	return snapshots, nil
+}
+
+// orderForDeletion returns references to objects sorted by DELETION_ORDER. Kinds without a rank are
+// deleted after the ranked ones, in the order given.
+func orderForDeletion(objects []*unstructured.Unstructured) []*unstructured.Unstructured {
+	var references = make([]*unstructured.Unstructured, len(objects))
+	for index, object := range objects {
+		references[index] = deletionReference(object)
+	}
+
+	sort.SliceStable(references, func(i, j int) bool {
+		return deletionRank(references[i]) < deletionRank(references[j])
+	})
+
+	return references
+}
+
+func deletionRank(object *unstructured.Unstructured) int {
+	if rank, ok := DELETION_ORDER[object.GetKind()]; ok {
+		return rank
+	}
+	return len(DELETION_ORDER)
+}
+
+// deletionReference returns the apiVersion, kind, namespace and name of object. References without a
+// name keep the labels that select the objects to delete.
+func deletionReference(object *unstructured.Unstructured) *unstructured.Unstructured {
+	if len(object.GetName()) == 0 {
+		return selectorReference(object.GetAPIVersion(), object.GetKind(), object.GetNamespace(), object.GetLabels())
+	}
+
+	var reference unstructured.Unstructured
+	reference.SetAPIVersion(object.GetAPIVersion())
+	reference.SetKind(object.GetKind())
+	reference.SetNamespace(object.GetNamespace())
+	reference.SetName(object.GetName())
+	return &reference
+}
+
+// selectorReference refers to every object of kind in namespace that has labels.
+func selectorReference(apiVersion, kind, namespace string, labels map[string]string) *unstructured.Unstructured {
+	var reference unstructured.Unstructured
+	reference.SetAPIVersion(apiVersion)
+	reference.SetKind(kind)
+	reference.SetNamespace(namespace)
+	reference.SetLabels(labels)
+	return &reference
+}
+
+// instanceDataVolumes returns the volumes of an instance.
+func instanceDataVolumes(instance entity.InstanceIF) ([]entity.DataVolume, bool) {
+	if zcash, ok := asZcashInstance(instance); ok {
+		return []entity.DataVolume{zcash.DataVolume, zcash.ParamsVolume}, true
+	}
+	if lwd, ok := asLWDInstance(instance); ok {
+		return []entity.DataVolume{lwd.DataVolume}, true
+	}
+	if zebra, ok := instance.(*ZebraInstance); ok {
+		return []entity.DataVolume{zebra.DataVolume}, true
+	}
+	return nil, false
+}
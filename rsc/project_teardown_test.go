@@ -0,0 +1,104 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func Test_OrderForDeletion(t *testing.T) {
+	var objects []*unstructured.Unstructured
+	for _, kind := range []string{"PersistentVolumeClaim", "Secret", "Custom", "Service", "Deployment", "HTTPProxy", "SnapshotSchedule"} {
+		var object unstructured.Unstructured
+		object.SetKind(kind)
+		object.SetName("sample")
+		object.SetLabels(map[string]string{"platform": "zbi"})
+		objects = append(objects, &object)
+	}
+
+	var kinds []string
+	for _, object := range orderForDeletion(objects) {
+		assert.Empty(t, object.GetLabels())
+		kinds = append(kinds, object.GetKind())
+	}
+	assert.Equal(t, []string{"HTTPProxy", "SnapshotSchedule", "Deployment", "Service", "Secret", "PersistentVolumeClaim", "Custom"}, kinds)
+}
+
+func Test_CreateProjectDeletionAssets(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	project, _ := projManager.CreateProject(ctx, &request)
+
+	teardown := projManager.(ProjectTeardownManager)
+
+	assets, err := teardown.CreateProjectDeletionAssets(ctx, project, nil, nil, ProjectDeletionOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, assets.Deletions)
+	assert.Equal(t, "Namespace", assets.Deletions[len(assets.Deletions)-1].GetKind())
+
+	assets, err = teardown.CreateProjectDeletionAssets(ctx, project, nil, nil, ProjectDeletionOptions{KeepVolumes: true})
+	assert.NoError(t, err)
+	for _, deletion := range assets.Deletions {
+		assert.NotEqual(t, "Namespace", deletion.GetKind())
+	}
+}
+
+func Test_CreateInstanceDeletionAssets(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	zcashResource, err := NewZcashInstanceResourceManager(zcashConfig, testOptions())
+	assert.NoError(t, err)
+
+	managers := map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF{ztypes.InstanceTypeZCASH: zcashResource}
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, managers, testOptions())
+	assert.NoError(t, err)
+
+	zcash := &entity.ZcashInstance{
+		Instance: entity.Instance{Project: "sample", Name: "node", Version: "v1", InstanceType: ztypes.InstanceTypeZCASH},
+		ZcashDetails: entity.ZcashDetails{
+			DataVolume:   entity.DataVolume{Name: "node-data", Volume: "zcash-data"},
+			ParamsVolume: entity.DataVolume{Name: "node-params", Volume: "zcash-params"},
+		},
+	}
+
+	deletions, err := projManager.(*ProjectResourceManager).createInstanceDeletionAssets(ctx, zcash, ProjectDeletionOptions{})
+	assert.NoError(t, err)
+
+	var kinds []string
+	var claims []string
+	for _, deletion := range deletions {
+		kinds = append(kinds, deletion.GetKind())
+		if deletion.GetKind() == "PersistentVolumeClaim" {
+			claims = append(claims, deletion.GetName())
+			continue
+		}
+		assert.Empty(t, deletion.GetName())
+		assert.Equal(t, "node", deletion.GetLabels()["instance"])
+		assert.Equal(t, "sample", deletion.GetLabels()["project"])
+		assert.NotContains(t, deletion.GetLabels(), "version")
+	}
+	assert.Equal(t, []string{"HTTPProxy", "NetworkPolicy", "SnapshotSchedule", "Deployment", "Service", "ConfigMap", "Secret",
+		"PersistentVolumeClaim", "PersistentVolumeClaim"}, kinds)
+	assert.Equal(t, []string{"node-data", "node-params"}, claims)
+
+	deletions, err = projManager.(*ProjectResourceManager).createInstanceDeletionAssets(ctx, zcash, ProjectDeletionOptions{KeepVolumes: true})
+	assert.NoError(t, err)
+	for _, deletion := range deletions {
+		assert.NotEqual(t, "PersistentVolumeClaim", deletion.GetKind())
+	}
+}
@@ -0,0 +1,145 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/entity"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ProjectUpgradePlan lists the changes that move the assets of a project from one template version to
+// another. Nothing is changed by creating a plan; the project entity is only moved to ToVersion by
+// AcceptProjectUpgrade once the plan has been applied.
+type ProjectUpgradePlan struct {
+	Project     string
+	FromVersion string
+	ToVersion   string
+
+	// Create holds objects rendered only by ToVersion.
+	Create []*unstructured.Unstructured
+
+	// Patch holds the ToVersion rendering of objects both versions render differently.
+	Patch []*unstructured.Unstructured
+
+	// Delete holds references to objects rendered only by FromVersion, in deletion order.
+	Delete []*unstructured.Unstructured
+}
+
+// Empty reports whether both versions render the same objects.
+func (p *ProjectUpgradePlan) Empty() bool {
+	return len(p.Create) == 0 && len(p.Patch) == 0 && len(p.Delete) == 0
+}
+
+// ProjectUpgradeManager is implemented by project resource managers that can move a project to another
+// template version.
+type ProjectUpgradeManager interface {
+	UpgradeProject(ctx context.Context, project *entity.Project, targetVersion string) (*ProjectUpgradePlan, error)
+	AcceptProjectUpgrade(ctx context.Context, project *entity.Project, plan *ProjectUpgradePlan) error
+}
+
+// UpgradeProject renders the project assets under its current version and under targetVersion and
+// returns what to create, patch and delete. The project is not modified.
+func (p *ProjectResourceManager) UpgradeProject(ctx context.Context, project *entity.Project, targetVersion string) (*ProjectUpgradePlan, error) {
+
+	var errors ValidationErrors
+	if len(targetVersion) == 0 {
+		errors.Add("version", targetVersion, "is required")
+	} else if targetVersion == project.Version {
+		errors.Add("version", targetVersion, "is already the version of project %s", project.Name)
+	} else if _, ok := p.GetProjectResources(targetVersion); !ok {
+		errors.Add("version", targetVersion, "is not an available project version")
+	}
+
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Project %s cannot be upgraded - %s", project.Name, err)
+		return nil, err
+	}
+
+	current, err := p.CreateProjectAssets(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var upgraded = *project
+	upgraded.Version = targetVersion
+	target, err := p.CreateProjectAssets(ctx, &upgraded)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := diffAssets(current, target)
+	plan.Project = project.Name
+	plan.FromVersion = project.Version
+	plan.ToVersion = targetVersion
+
+	logger.Debugf(ctx, "Upgrade of project %s from %s to %s creates %d, patches %d and deletes %d objects",
+		project.Name, plan.FromVersion, plan.ToVersion, len(plan.Create), len(plan.Patch), len(plan.Delete))
+	return plan, nil
+}
+
+// AcceptProjectUpgrade records on the project that the plan has been applied. A plan made for another
+// project, or before the project last changed version, is rejected.
+func (p *ProjectResourceManager) AcceptProjectUpgrade(ctx context.Context, project *entity.Project, plan *ProjectUpgradePlan) error {
+
+	var errors ValidationErrors
+	if plan.Project != project.Name {
+		errors.Add("project", plan.Project, "plan was made for another project")
+	}
+	if plan.FromVersion != project.Version {
+		errors.Add("version", plan.FromVersion, "plan was made for version %s but the project is at %s", plan.FromVersion, project.Version)
+	}
+	if _, ok := p.GetProjectResources(plan.ToVersion); !ok {
+		errors.Add("version", plan.ToVersion, "is not an available project version")
+	}
+
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Upgrade plan for project %s not accepted - %s", project.Name, err)
+		return err
+	}
+
+	project.Version = plan.ToVersion
+	project.Action = "upgraded"
+	project.ActionTime = p.opts.now()
+
+	return nil
+}
+
+// diffAssets compares two renderings of the same project. Objects are matched by kind, namespace and
+// name so that a change of apiVersion is a patch rather than a delete and create.
+func diffAssets(current, target []*unstructured.Unstructured) *ProjectUpgradePlan {
+	var plan ProjectUpgradePlan
+
+	var existing = make(map[string]*unstructured.Unstructured, len(current))
+	for _, object := range current {
+		existing[assetKey(object)] = object
+	}
+
+	var rendered = make(map[string]bool, len(target))
+	for _, object := range target {
+		key := assetKey(object)
+		rendered[key] = true
+
+		if previous, ok := existing[key]; !ok {
+			plan.Create = append(plan.Create, object)
+		} else if !reflect.DeepEqual(previous.Object, object.Object) {
+			plan.Patch = append(plan.Patch, object)
+		}
+	}
+
+	var removed []*unstructured.Unstructured
+	for _, object := range current {
+		if !rendered[assetKey(object)] {
+			removed = append(removed, object)
+		}
+	}
+	plan.Delete = orderForDeletion(removed)
+
+	return &plan
+}
+
+func assetKey(object *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", object.GetKind(), object.GetNamespace(), object.GetName())
+}
@@ -0,0 +1,74 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func sampleAsset(kind, name string, data map[string]interface{}) *unstructured.Unstructured {
+	var asset = unstructured.Unstructured{Object: map[string]interface{}{"data": data}}
+	asset.SetAPIVersion("v1")
+	asset.SetKind(kind)
+	asset.SetNamespace("sample")
+	asset.SetName(name)
+	return &asset
+}
+
+func Test_DiffAssets(t *testing.T) {
+	current := []*unstructured.Unstructured{
+		sampleAsset("ConfigMap", "unchanged", map[string]interface{}{"key": "value"}),
+		sampleAsset("ConfigMap", "changed", map[string]interface{}{"key": "v1"}),
+		sampleAsset("Service", "removed", nil),
+		sampleAsset("NetworkPolicy", "removed", nil),
+	}
+	target := []*unstructured.Unstructured{
+		sampleAsset("ConfigMap", "unchanged", map[string]interface{}{"key": "value"}),
+		sampleAsset("ConfigMap", "changed", map[string]interface{}{"key": "v2"}),
+		sampleAsset("Secret", "added", nil),
+	}
+
+	plan := diffAssets(current, target)
+	assert.False(t, plan.Empty())
+
+	assert.Len(t, plan.Create, 1)
+	assert.Equal(t, "added", plan.Create[0].GetName())
+
+	assert.Len(t, plan.Patch, 1)
+	assert.Equal(t, "changed", plan.Patch[0].GetName())
+
+	assert.Len(t, plan.Delete, 2)
+	assert.Equal(t, "NetworkPolicy", plan.Delete[0].GetKind())
+	assert.Equal(t, "Service", plan.Delete[1].GetKind())
+
+	assert.True(t, diffAssets(current, current).Empty())
+}
+
+func Test_UpgradeProject(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project, nil, testOptions())
+	assert.NoErrorf(t, err, "Failed to create project resource manager")
+
+	var request = object.ProjectRequest{Name: "sample", Version: "v1", Network: ztypes.NetworkTypeTest, Description: "Sample Project", Team: "team1"}
+	request.SetOwner("admin")
+	project, _ := projManager.CreateProject(ctx, &request)
+
+	upgrade := projManager.(ProjectUpgradeManager)
+
+	_, err = upgrade.UpgradeProject(ctx, project, "v1")
+	assert.Error(t, err)
+
+	_, err = upgrade.UpgradeProject(ctx, project, "v0")
+	assert.Error(t, err)
+
+	plan := &ProjectUpgradePlan{Project: project.Name, FromVersion: "v0", ToVersion: "v1"}
+	assert.Error(t, upgrade.AcceptProjectUpgrade(ctx, project, plan))
+	assert.Equal(t, "v1", project.Version)
+}
@@ -0,0 +1,81 @@
+package rsc
+
+import (
+	"github.com/zbitech/common/pkg/model/spec"
+)
+
+// ResourceQuota sizes the ResourceQuota and LimitRange of a project namespace. Quantities use the
+// Kubernetes notation ("4", "500m", "16Gi"); empty values and zero counts are left unbounded.
+type ResourceQuota struct {
+	// CPU and Memory cap the sum of the limits of every container in the project.
+	CPU    string `json:"cpu" yaml:"cpu"`
+	Memory string `json:"memory" yaml:"memory"`
+
+	// PersistentVolumeClaims and Storage cap the number and the total requested size of volumes.
+	PersistentVolumeClaims int    `json:"persistentVolumeClaims" yaml:"persistentVolumeClaims"`
+	Storage                string `json:"storage" yaml:"storage"`
+
+	// Snapshots caps the number of volume snapshots kept in the project.
+	Snapshots int `json:"snapshots" yaml:"snapshots"`
+
+	// Container limits and requests are applied by the LimitRange to containers that do not declare
+	// their own. MaxContainerCPU and MaxContainerMemory bound what a single container may ask for.
+	ContainerCPU           string `json:"containerCpu" yaml:"containerCpu"`
+	ContainerMemory        string `json:"containerMemory" yaml:"containerMemory"`
+	ContainerRequestCPU    string `json:"containerRequestCpu" yaml:"containerRequestCpu"`
+	ContainerRequestMemory string `json:"containerRequestMemory" yaml:"containerRequestMemory"`
+	MaxContainerCPU        string `json:"maxContainerCpu" yaml:"maxContainerCpu"`
+	MaxContainerMemory     string `json:"maxContainerMemory" yaml:"maxContainerMemory"`
+}
+
+// QuotaPolicy selects the quota of a project from its team. A team assigned to a plan gets that plan's
+// quota, a team with its own entry in TeamQuotas gets that instead, and every other team gets Default.
+type QuotaPolicy struct {
+	Default    ResourceQuota            `json:"default" yaml:"default"`
+	Plans      map[string]ResourceQuota `json:"plans" yaml:"plans"`
+	TeamPlans  map[string]string        `json:"teamPlans" yaml:"teamPlans"`
+	TeamQuotas map[string]ResourceQuota `json:"teamQuotas" yaml:"teamQuotas"`
+}
+
+// DefaultQuotaPolicy leaves room for a handful of full nodes per project.
+func DefaultQuotaPolicy() QuotaPolicy {
+	return QuotaPolicy{
+		Default: ResourceQuota{
+			CPU:                    "16",
+			Memory:                 "64Gi",
+			PersistentVolumeClaims: 10,
+			Storage:                "1Ti",
+			Snapshots:              20,
+			ContainerCPU:           "500m",
+			ContainerMemory:        "512Mi",
+			ContainerRequestCPU:    "100m",
+			ContainerRequestMemory: "128Mi",
+			MaxContainerCPU:        "8",
+			MaxContainerMemory:     "32Gi",
+		},
+	}
+}
+
+// QuotaFor returns the quota that applies to projects of team.
+func (q QuotaPolicy) QuotaFor(team string) ResourceQuota {
+	if quota, ok := q.TeamQuotas[team]; ok {
+		return quota
+	}
+
+	if plan, ok := q.TeamPlans[team]; ok {
+		if quota, ok := q.Plans[plan]; ok {
+			return quota
+		}
+	}
+
+	return q.Default
+}
+
+// projectSpec adds the project quota, service account and authorization server to the spec the project
+// templates are rendered with.
+type projectSpec struct {
+	spec.ProjectSpec
+	ServiceAccountName string
+	Quota              ResourceQuota
+	Authz              AuthzSpec
+}
@@ -0,0 +1,23 @@
+package rsc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_QuotaFor(t *testing.T) {
+	var small = ResourceQuota{CPU: "2", Memory: "8Gi", PersistentVolumeClaims: 2, Storage: "50Gi", Snapshots: 2}
+	var archive = ResourceQuota{CPU: "32", Memory: "128Gi", PersistentVolumeClaims: 20, Storage: "4Ti", Snapshots: 40}
+	var custom = ResourceQuota{CPU: "4", Memory: "16Gi"}
+
+	policy := DefaultQuotaPolicy()
+	policy.Plans = map[string]ResourceQuota{"small": small, "archive": archive}
+	policy.TeamPlans = map[string]string{"team1": "small", "team2": "archive", "team3": "unknown", "team4": "archive"}
+	policy.TeamQuotas = map[string]ResourceQuota{"team4": custom}
+
+	assert.Equal(t, small, policy.QuotaFor("team1"))
+	assert.Equal(t, archive, policy.QuotaFor("team2"))
+	assert.Equal(t, policy.Default, policy.QuotaFor("team3"))
+	assert.Equal(t, custom, policy.QuotaFor("team4"))
+	assert.Equal(t, policy.Default, policy.QuotaFor("team5"))
+}
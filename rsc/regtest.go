@@ -0,0 +1,32 @@
+package rsc
+
+import (
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const (
+	NetworkTypeRegtest ztypes.NetworkType = "regtest"
+
+	REGTEST_PEER_PORT          = 18344
+	MAX_REGTEST_BLOCK_INTERVAL = 24 * 60 * 60
+)
+
+var (
+	// REGTEST_UPGRADES are the consensus branch ids of the network upgrades (Overwinter to Canopy) that
+	// regtest chains activate at height 1, so that they validate transactions like the public networks.
+	REGTEST_UPGRADES = []string{"5ba81b19", "76b809bb", "2bb40e60", "f5b9230b", "e9ff75a6"}
+)
+
+// validateBlockInterval checks that blocks are only generated on regtest chains, at most once a second
+// and at least once a day.
+func validateBlockInterval(errors *ValidationErrors, network ztypes.NetworkType, interval int32) {
+	if interval == 0 {
+		return
+	}
+
+	if network != NetworkTypeRegtest {
+		errors.Add("blockInterval", interval, "is only supported on %s projects", NetworkTypeRegtest)
+	} else if interval < 0 || interval > MAX_REGTEST_BLOCK_INTERVAL {
+		errors.Add("blockInterval", interval, "must be between 1 and %d seconds", MAX_REGTEST_BLOCK_INTERVAL)
+	}
+}
@@ -0,0 +1,21 @@
+package rsc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+func Test_ValidateBlockInterval(t *testing.T) {
+	var errs ValidationErrors
+	validateBlockInterval(&errs, NetworkTypeRegtest, 0)
+	validateBlockInterval(&errs, NetworkTypeRegtest, 30)
+	validateBlockInterval(&errs, ztypes.NetworkTypeTest, 0)
+	assert.NoError(t, errs.Err())
+
+	validateBlockInterval(&errs, ztypes.NetworkTypeTest, 30)
+	validateBlockInterval(&errs, NetworkTypeRegtest, -1)
+	validateBlockInterval(&errs, NetworkTypeRegtest, MAX_REGTEST_BLOCK_INTERVAL+1)
+	assert.Len(t, errs, 3)
+}
@@ -0,0 +1,62 @@
+package rsc
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"text/template"
+
+	"github.com/zbitech/common/pkg/model/config"
+)
+
+// InstanceResourceReloader is implemented by instance resource managers whose versioned
+// templates can be replaced while the manager is in use. PrepareReload parses and validates
+// every version of cfg without touching the active configuration and returns a commit
+// function that swaps the new configuration in.
+type InstanceResourceReloader interface {
+	PrepareReload(ctx context.Context, cfg *config.InstanceResourceConfig) (func(), error)
+}
+
+type ProjectResourceReloader interface {
+	PrepareReload(ctx context.Context, cfg *config.ProjectResourceConfig) (func(), error)
+}
+
+type AppResourceReloader interface {
+	PrepareReload(ctx context.Context, cfg *config.AppResourceConfig) (func(), error)
+}
+
+// ResourceConfigReloader is implemented by managers that read the configuration of other managers from
+// the resource config. PrepareResourceConfigReload returns a commit function that swaps rscConfig in.
+type ResourceConfigReloader interface {
+	PrepareResourceConfigReload(ctx context.Context, rscConfig *config.ResourceConfig) (func(), error)
+}
+
+// loadVersions copies the configuration of each version and parses its template file from assets. Managers
+// keep the copies, so they never share or modify the configuration they were created with, and a broken
+// template leaves the versions currently in use untouched.
+func loadVersions(assets fs.FS, versions map[string]*config.VersionedResourceConfig, funcs template.FuncMap) (map[string]*config.VersionedResourceConfig, map[string]*FileTemplate, error) {
+	var loaded = make(map[string]*config.VersionedResourceConfig, len(versions))
+	var templates = make(map[string]*FileTemplate, len(versions))
+	for version, cfg := range versions {
+		var versionCfg = *cfg
+		fileTemplate, err := loadFileTemplate(assets, &versionCfg, funcs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("version %s - %s", version, err)
+		}
+		loaded[version] = &versionCfg
+		templates[version] = fileTemplate
+	}
+
+	return loaded, templates, nil
+}
+
+func loadInstanceConfig(assets fs.FS, cfg *config.InstanceResourceConfig, funcs template.FuncMap) (*config.InstanceResourceConfig, map[string]*FileTemplate, error) {
+	versions, templates, err := loadVersions(assets, cfg.Versions, funcs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var instanceCfg = *cfg
+	instanceCfg.Versions = versions
+	return &instanceCfg, templates, nil
+}
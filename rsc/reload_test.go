@@ -0,0 +1,34 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/vars"
+	"testing"
+)
+
+func Test_PrepareReload(t *testing.T) {
+	ctx := context.Background()
+	factory.InitProjectResourceConfig(ctx)
+
+	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	zcashResource, err := NewZcashInstanceResourceManager(zcashConfig, testOptions())
+	assert.NoError(t, err)
+
+	zcashManager := zcashResource.(*ZcashInstanceResourceManager)
+	current, _ := zcashManager.GetInstanceResources("v1")
+
+	commit, err := zcashManager.PrepareReload(ctx, zcashConfig)
+	assert.NoError(t, err)
+	assert.NotNil(t, commit)
+
+	pending, _ := zcashManager.GetInstanceResources("v1")
+	assert.Same(t, current, pending, "Configuration swapped before commit")
+
+	commit()
+	reloaded, ok := zcashManager.GetInstanceResources("v1")
+	assert.True(t, ok)
+	assert.NotSame(t, current, reloaded, "Configuration not swapped after commit")
+}
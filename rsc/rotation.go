@@ -0,0 +1,109 @@
+package rsc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zbitech/common/pkg/id"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CredentialExpiryManager is implemented by managers whose instances keep accepting replaced credentials
+// for a while after a rotation.
+type CredentialExpiryManager interface {
+	CreateCredentialExpiryAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error)
+}
+
+// credentialRotator is implemented by managers that can render the rotation of credentials before
+// recording them, so that a failed rotation leaves the instance unchanged.
+type credentialRotator interface {
+	prepareRotation(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, func(), error)
+}
+
+// rpcAuth returns the zcash.conf rpcauth entry that accepts username and password. Only a salted hash of
+// the password is kept.
+func rpcAuth(username, password string) (string, error) {
+	if len(username) == 0 || strings.ContainsAny(username, ":\r\n") {
+		return "", fmt.Errorf("invalid rpc username %q", username)
+	}
+
+	var salt = make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	saltHex := hex.EncodeToString(salt)
+	mac := hmac.New(sha256.New, []byte(saltHex))
+	mac.Write([]byte(password))
+	return fmt.Sprintf("%s:%s$%s", username, saltHex, hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+// rpcCredentials are credentials generated for a zcash node that have not been rendered into its
+// credentials Secret yet. They are never stored; the Secret is the only copy of the password.
+type rpcCredentials struct {
+	username string
+	password string
+}
+
+// newCredentials generates new credentials for zcash and records them.
+func (o Options) newCredentials(zcash *ZcashInstance) error {
+	return o.recordCredentials(zcash, id.GenerateUserName(), id.GenerateSecurePassword())
+}
+
+// recordCredentials records the rpcauth entry of the new credentials of zcash and keeps the credentials
+// until zcash is rendered. When CredentialOverlap is set, the entry of the previous credentials is kept,
+// and rendered, until the overlap ends.
+func (o Options) recordCredentials(zcash *ZcashInstance, username, password string) error {
+	entry, err := rpcAuth(username, password)
+	if err != nil {
+		return err
+	}
+
+	if o.CredentialOverlap > 0 && len(zcash.RPCAuth) > 0 {
+		zcash.RPCAuth = []string{entry, zcash.RPCAuth[0]}
+		zcash.RPCAuthExpires = o.now().Add(o.CredentialOverlap)
+	} else {
+		zcash.RPCAuth = []string{entry}
+		zcash.RPCAuthExpires = time.Time{}
+	}
+	zcash.credentials = rpcCredentials{username: username, password: password}
+	return nil
+}
+
+// previousCredentials returns the rpcauth entries of replaced credentials zcash still accepts at now.
+// The current credentials are passed to the node from its Secret.
+func previousCredentials(zcash *ZcashInstance, now time.Time) []string {
+	if len(zcash.RPCAuth) < 2 || !now.Before(zcash.RPCAuthExpires) {
+		return nil
+	}
+	return zcash.RPCAuth[1:]
+}
+
+// dependentInstances returns the instances of the project of instance that connect to it. Lightwalletd
+// servers depend on the zcash node they read the chain from.
+func (p *ProjectResourceManager) dependentInstances(ctx context.Context, instance entity.InstanceIF) ([]entity.InstanceIF, error) {
+	if p.opts.Instances == nil || instance.GetInstanceType() != ztypes.InstanceTypeZCASH {
+		return nil, nil
+	}
+
+	instances, err := p.opts.Instances(ctx, instance.GetProject())
+	if err != nil {
+		return nil, err
+	}
+
+	var dependents []entity.InstanceIF
+	for _, candidate := range instances {
+		if lwd, ok := asLWDInstance(candidate); ok && lwd.ZcashInstance == instance.GetName() {
+			dependents = append(dependents, candidate)
+		}
+	}
+	return dependents, nil
+}
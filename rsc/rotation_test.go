@@ -0,0 +1,81 @@
+package rsc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+func Test_RPCAuth(t *testing.T) {
+	entry, err := rpcAuth("user", "secret")
+	assert.NoError(t, err)
+	assert.NotContains(t, entry, "secret")
+
+	parts := strings.SplitN(strings.TrimPrefix(entry, "user:"), "$", 2)
+	assert.Len(t, parts, 2)
+	mac := hmac.New(sha256.New, []byte(parts[0]))
+	mac.Write([]byte("secret"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), parts[1])
+
+	_, err = rpcAuth("us:er", "secret")
+	assert.Error(t, err)
+}
+
+func Test_RecordCredentials(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	zcash := &ZcashInstance{}
+
+	opts := Options{Clock: func() time.Time { return now }}
+
+	assert.NoError(t, opts.recordCredentials(zcash, "user1", "password1"))
+	assert.NoError(t, opts.recordCredentials(zcash, "user2", "password2"))
+	assert.Equal(t, rpcCredentials{username: "user2", password: "password2"}, zcash.credentials)
+	assert.Len(t, zcash.RPCAuth, 1)
+	assert.True(t, strings.HasPrefix(zcash.RPCAuth[0], "user2:"))
+	assert.Empty(t, previousCredentials(zcash, now))
+
+	opts.CredentialOverlap = time.Hour
+	assert.NoError(t, opts.recordCredentials(zcash, "user3", "password3"))
+	assert.Len(t, zcash.RPCAuth, 2)
+	assert.True(t, strings.HasPrefix(zcash.RPCAuth[1], "user2:"))
+	assert.Equal(t, zcash.RPCAuth[1:], previousCredentials(zcash, now.Add(time.Minute)))
+	assert.Empty(t, previousCredentials(zcash, now.Add(time.Hour)))
+
+	conf := NewZcashConf(ztypes.NetworkTypeTest, false, false)
+	conf.AddRPCAuth(previousCredentials(zcash, now)...)
+	assert.Contains(t, conf.Value(), "    rpcauth=user2:")
+}
+
+func Test_DependentInstances(t *testing.T) {
+	ctx := context.Background()
+	zcash := &entity.ZcashInstance{Instance: entity.Instance{Project: "project", Name: "node1", InstanceType: ztypes.InstanceTypeZCASH}}
+	lwd := func(name, node string) *entity.LWDInstance {
+		return &entity.LWDInstance{Instance: entity.Instance{Project: "project", Name: name, InstanceType: ztypes.InstanceTypeLWD}, ZcashInstance: node}
+	}
+
+	var p ProjectResourceManager
+	dependents, err := p.dependentInstances(ctx, zcash)
+	assert.NoError(t, err)
+	assert.Empty(t, dependents)
+
+	p.opts.Instances = func(ctx context.Context, project string) ([]entity.InstanceIF, error) {
+		return []entity.InstanceIF{zcash, lwd("lwd1", "node1"), lwd("lwd2", "node2"), lwd("lwd3", "node1")}, nil
+	}
+	dependents, err = p.dependentInstances(ctx, zcash)
+	assert.NoError(t, err)
+	assert.Len(t, dependents, 2)
+	assert.Equal(t, "lwd1", dependents[0].GetName())
+	assert.Equal(t, "lwd3", dependents[1].GetName())
+
+	dependents, err = p.dependentInstances(ctx, lwd("lwd1", "node1"))
+	assert.NoError(t, err)
+	assert.Empty(t, dependents)
+}
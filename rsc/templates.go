@@ -0,0 +1,95 @@
+package rsc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"text/template"
+
+	"github.com/zbitech/common/pkg/model/config"
+)
+
+// COMMON_TEMPLATE_FILE holds the templates shared by the template files next to it, such as the sync probe
+// and the container resources of instance pods.
+const COMMON_TEMPLATE_FILE = "common_templates.tmpl"
+
+// FileTemplate is the parsed template file of one version of a resource config.
+type FileTemplate struct {
+	tmpl   *template.Template
+	policy VersionPolicy
+}
+
+// loadFileTemplate parses the template file of cfg from assets, with the shared templates of its directory
+// when there are any. The file name in the resource config is relative to the root of assets.
+func loadFileTemplate(assets fs.FS, cfg *config.VersionedResourceConfig, funcs template.FuncMap) (*FileTemplate, error) {
+	if assets == nil {
+		return nil, fmt.Errorf("no template assets configured")
+	}
+
+	name := path.Clean(cfg.Templates.File)
+	data, err := fs.ReadFile(assets, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(path.Base(name)).Funcs(funcs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	common, err := fs.ReadFile(assets, path.Join(path.Dir(name), COMMON_TEMPLATE_FILE))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if _, err = tmpl.New(COMMON_TEMPLATE_FILE).Parse(string(common)); err != nil {
+			return nil, err
+		}
+	}
+
+	fileTemplate := &FileTemplate{tmpl: tmpl}
+	if fileTemplate.policy, err = decodeVersionPolicy(fileTemplate); err != nil {
+		return nil, err
+	}
+	return fileTemplate, nil
+}
+
+// versionPolicy returns the policy declared by the VERSION_POLICY template of the version. Versions
+// without one, and a nil FileTemplate, have an empty policy.
+func (t *FileTemplate) versionPolicy() VersionPolicy {
+	if t == nil {
+		return VersionPolicy{}
+	}
+	return t.policy
+}
+
+// ExecuteTemplate renders the template called name with data. Executing a nil FileTemplate reports the
+// template as missing.
+func (t *FileTemplate) ExecuteTemplate(name string, data interface{}) (string, error) {
+	if t == nil {
+		return "", fmt.Errorf("no template %q loaded", name)
+	}
+
+	var buffer bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&buffer, name, data); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// ExecuteTemplates renders each template in names with data, in order.
+func (t *FileTemplate) ExecuteTemplates(names []string, data interface{}) ([]string, error) {
+	var results = make([]string, 0, len(names))
+	for _, name := range names {
+		result, err := t.ExecuteTemplate(name, data)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
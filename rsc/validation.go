@@ -0,0 +1,85 @@
+package rsc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const (
+	// MaxProjectNameLength keeps the namespace and the names derived from it (service accounts,
+	// roles, proxies) within the 63 character limit of a DNS-1123 label.
+	MaxProjectNameLength = 40
+)
+
+var (
+	dns1123LabelRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+	KNOWN_NETWORKS = map[ztypes.NetworkType]bool{
+		ztypes.NetworkTypeMain: true,
+		ztypes.NetworkTypeTest: true,
+		NetworkTypeRegtest:     true,
+	}
+)
+
+// FieldError describes why the value of a single request field was rejected.
+type FieldError struct {
+	Field   string      `json:"field"`
+	Value   interface{} `json:"value,omitempty"`
+	Message string      `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects every field error found in a request so that all of them can be
+// reported to the user at once.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	var messages = make([]string, len(v))
+	for index, e := range v {
+		messages[index] = e.Error()
+	}
+	return fmt.Sprintf("invalid request - %s", strings.Join(messages, "; "))
+}
+
+// Add records a field error.
+func (v *ValidationErrors) Add(field string, value interface{}, format string, args ...interface{}) {
+	*v = append(*v, FieldError{Field: field, Value: value, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns the collected errors, or nil when the request is valid.
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// validateDNS1123Label checks that value can be used as a Kubernetes object name or namespace.
+func validateDNS1123Label(errors *ValidationErrors, field, value string, maxLength int) {
+	if len(value) == 0 {
+		errors.Add(field, value, "is required")
+		return
+	}
+
+	if len(value) > maxLength {
+		errors.Add(field, value, "must be no more than %d characters", maxLength)
+	}
+
+	if !dns1123LabelRegexp.MatchString(value) {
+		errors.Add(field, value, "must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character")
+	}
+}
+
+func validateNetwork(errors *ValidationErrors, field string, network ztypes.NetworkType) {
+	if len(network) == 0 {
+		errors.Add(field, network, "is required")
+	} else if !KNOWN_NETWORKS[network] {
+		errors.Add(field, network, "is not a supported network")
+	}
+}
@@ -0,0 +1,38 @@
+package rsc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"strings"
+	"testing"
+)
+
+func Test_ValidateDNS1123Label(t *testing.T) {
+	var cases = map[string]int{
+		"sample":                0,
+		"sample-1":              0,
+		"":                      1,
+		"Sample":                1,
+		"-sample":               1,
+		"sample_1":              1,
+		strings.Repeat("a", 41): 1,
+		strings.Repeat("A", 41): 2,
+	}
+
+	for value, count := range cases {
+		var errors ValidationErrors
+		validateDNS1123Label(&errors, "name", value, MaxProjectNameLength)
+		assert.Lenf(t, errors, count, "unexpected errors for %q - %v", value, errors)
+	}
+}
+
+func Test_ValidateNetwork(t *testing.T) {
+	var errors ValidationErrors
+	validateNetwork(&errors, "network", ztypes.NetworkTypeTest)
+	assert.NoError(t, errors.Err())
+
+	validateNetwork(&errors, "network", "")
+	validateNetwork(&errors, "network", "devnet")
+	assert.Len(t, errors, 2)
+	assert.Equal(t, "network", errors[1].Field)
+}
@@ -0,0 +1,83 @@
+package rsc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// VERSION_POLICY is the optional template of a version that sizes the volumes and containers of its
+// instances. The versioned resource config of the common module has no fields for them, so each version
+// declares them in its template file, which is loaded and reloaded with the rest of the version.
+const VERSION_POLICY = "VERSION_POLICY"
+
+// VersionPolicy holds the volume bounds and resource profiles of one version, rendered from its
+// VERSION_POLICY template as YAML. Volumes replace the bounds of Options.Volumes they name; Profiles,
+// when set, replace the profiles of Options.Profiles for the instance type of the version.
+type VersionPolicy struct {
+	Volumes        VolumePolicy               `json:"volumes,omitempty"`
+	DefaultProfile string                     `json:"defaultProfile,omitempty"`
+	Profiles       map[string]ResourceProfile `json:"profiles,omitempty"`
+}
+
+// decodeVersionPolicy decodes the VERSION_POLICY template of tmpl, when it has one.
+func decodeVersionPolicy(tmpl *FileTemplate) (VersionPolicy, error) {
+	var policy VersionPolicy
+	if tmpl.tmpl.Lookup(VERSION_POLICY) == nil {
+		return policy, nil
+	}
+
+	data, err := tmpl.ExecuteTemplate(VERSION_POLICY, nil)
+	if err != nil {
+		return policy, err
+	}
+
+	if err = yaml.NewYAMLOrJSONDecoder(strings.NewReader(data), len(data)+1).Decode(&policy); err != nil && err != io.EOF {
+		return policy, fmt.Errorf("%s is invalid - %s", VERSION_POLICY, err)
+	}
+
+	if len(policy.DefaultProfile) > 0 {
+		if _, ok := policy.Profiles[policy.DefaultProfile]; !ok {
+			return policy, fmt.Errorf("%s default profile %s is not defined", VERSION_POLICY, policy.DefaultProfile)
+		}
+	}
+	return policy, nil
+}
+
+// volumePolicy returns the volume policy of the version loaded as fileTemplate.
+func (o Options) volumePolicy(fileTemplate *FileTemplate) VolumePolicy {
+	versionPolicy := fileTemplate.versionPolicy()
+	if len(versionPolicy.Volumes) == 0 {
+		return o.Volumes
+	}
+
+	var policy = make(VolumePolicy, len(o.Volumes)+len(versionPolicy.Volumes))
+	for volume, size := range o.Volumes {
+		policy[volume] = size
+	}
+	for volume, size := range versionPolicy.Volumes {
+		policy[volume] = size
+	}
+	return policy
+}
+
+// profilePolicy returns the profile policy of instances of iType of the version loaded as fileTemplate.
+func (o Options) profilePolicy(iType ztypes.InstanceType, fileTemplate *FileTemplate) ProfilePolicy {
+	versionPolicy := fileTemplate.versionPolicy()
+	if len(versionPolicy.Profiles) == 0 {
+		return o.Profiles
+	}
+
+	var policy = ProfilePolicy{Default: o.Profiles.Default, Profiles: make(map[ztypes.InstanceType]map[string]ResourceProfile, len(o.Profiles.Profiles))}
+	for profileType, profiles := range o.Profiles.Profiles {
+		policy.Profiles[profileType] = profiles
+	}
+	policy.Profiles[iType] = versionPolicy.Profiles
+	if len(versionPolicy.DefaultProfile) > 0 {
+		policy.Default = versionPolicy.DefaultProfile
+	}
+	return policy
+}
@@ -0,0 +1,60 @@
+package rsc
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const sampleVersionPolicy = `{{define "VERSION_POLICY"}}
+volumes:
+  zcash-data: {default: 500, min: 300, max: 2000}
+defaultProfile: archive
+profiles:
+  archive:
+    node: {requestCpu: "2", requestMemory: 8Gi, limitCpu: "4", limitMemory: 16Gi}
+{{end}}`
+
+// loadPolicyTemplate parses a template file holding policy.
+func loadPolicyTemplate(policy string) (*FileTemplate, error) {
+	var cfg config.VersionedResourceConfig
+	cfg.Templates.File = "templates/zcash_templates_v1.tmpl"
+	assets := fstest.MapFS{cfg.Templates.File: &fstest.MapFile{Data: []byte(policy)}}
+	return loadFileTemplate(assets, &cfg, nil)
+}
+
+func Test_VersionPolicy(t *testing.T) {
+	fileTemplate, err := loadPolicyTemplate(sampleVersionPolicy)
+	assert.NoError(t, err)
+
+	opts := DefaultOptions()
+	volumes := opts.volumePolicy(fileTemplate)
+	assert.Equal(t, VolumeSize{Default: 500, Min: 300, Max: 2000}, volumes["zcash-data"])
+	assert.Equal(t, opts.Volumes["zcash-params"], volumes["zcash-params"])
+	assert.Equal(t, VolumeSize{Default: 10, Min: 5, Max: 1000}, opts.Volumes["zcash-data"])
+
+	profiles := opts.profilePolicy(ztypes.InstanceTypeZCASH, fileTemplate)
+	assert.Equal(t, ARCHIVE_PROFILE, profiles.Default)
+	assert.Len(t, profiles.Profiles[ztypes.InstanceTypeZCASH], 1)
+	assert.Equal(t, "16Gi", profiles.Profiles[ztypes.InstanceTypeZCASH][ARCHIVE_PROFILE]["node"].LimitMemory)
+	assert.Equal(t, opts.Profiles.Profiles[ztypes.InstanceTypeLWD], profiles.Profiles[ztypes.InstanceTypeLWD])
+	assert.Equal(t, STANDARD_PROFILE, opts.Profiles.Default)
+
+	var errors ValidationErrors
+	profiles.profileName(&errors, ztypes.InstanceTypeZCASH, SMALL_PROFILE)
+	assert.Len(t, errors, 1)
+
+	fileTemplate, err = loadPolicyTemplate(`{{define "NAMESPACE"}}{{end}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, opts.Volumes, opts.volumePolicy(fileTemplate))
+	assert.Equal(t, opts.Profiles, opts.profilePolicy(ztypes.InstanceTypeZCASH, fileTemplate))
+
+	_, err = loadPolicyTemplate(`{{define "VERSION_POLICY"}}volumes: [zcash-data]{{end}}`)
+	assert.Error(t, err)
+
+	_, err = loadPolicyTemplate(`{{define "VERSION_POLICY"}}defaultProfile: small{{end}}`)
+	assert.Error(t, err)
+}
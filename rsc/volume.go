@@ -0,0 +1,126 @@
+package rsc
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/spec"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+// VolumeSize bounds the size, in GiB, of a volume. Zero bounds are not checked.
+type VolumeSize struct {
+	Default int64 `json:"default" yaml:"default"`
+	Min     int64 `json:"min" yaml:"min"`
+	Max     int64 `json:"max" yaml:"max"`
+}
+
+// VolumePolicy sizes instance volumes, keyed by volume name. Volumes without a default size must be sized
+// by their requests.
+type VolumePolicy map[string]VolumeSize
+
+// DefaultVolumePolicy gives volumes the sizes instances had before they could be sized, and bounds the
+// sizes requests can set.
+func DefaultVolumePolicy() VolumePolicy {
+	return VolumePolicy{
+		"zcash-data":   {Default: 10, Min: 5, Max: 1000},
+		"zcash-params": {Default: 3, Min: 2, Max: 10},
+		"lwd-data":     {Default: 10, Min: 5, Max: 500},
+		"zebra-data":   {Default: 300, Min: 100, Max: 1000},
+	}
+}
+
+// volumeSizes returns the size in GiB of each volume of instResource. Requested sizes must name a volume
+// of the version and fall within its bounds; other volumes get their default size.
+func (p VolumePolicy) volumeSizes(errors *ValidationErrors, instResource *config.VersionedResourceConfig, requested map[string]int64) map[string]int64 {
+	var sizes = make(map[string]int64, len(instResource.Volumes))
+	for _, volume := range instResource.Volumes {
+		bounds := p[volume]
+
+		size, ok := requested[volume]
+		if !ok {
+			size = bounds.Default
+		}
+
+		validateVolumeSize(errors, fmt.Sprintf("volumeSizes[%s]", volume), size, bounds)
+		sizes[volume] = size
+	}
+
+	for _, volume := range sortedVolumes(requested) {
+		if _, ok := sizes[volume]; !ok {
+			errors.Add(fmt.Sprintf("volumeSizes[%s]", volume), requested[volume], "is not a volume of version %s", instResource.Version)
+		}
+	}
+
+	return sizes
+}
+
+func validateVolumeSize(errors *ValidationErrors, field string, size int64, bounds VolumeSize) {
+	if size <= 0 {
+		errors.Add(field, size, "must be a positive number of GiB")
+	} else if bounds.Min > 0 && size < bounds.Min {
+		errors.Add(field, size, "must be at least %d GiB", bounds.Min)
+	} else if bounds.Max > 0 && size > bounds.Max {
+		errors.Add(field, size, "must be no more than %d GiB", bounds.Max)
+	}
+}
+
+// resizeVolumes applies the sizes requested in an update to volumes. Volumes can grow but not shrink,
+// since claims cannot be reduced once bound. Nothing is changed when errors holds any error.
+func (p VolumePolicy) resizeVolumes(errors *ValidationErrors, instResource *config.VersionedResourceConfig, requested map[string]int64, volumes ...*entity.DataVolume) {
+	var known = make(map[string]*entity.DataVolume, len(volumes))
+	for _, volume := range volumes {
+		known[volume.Volume] = volume
+	}
+
+	for _, name := range sortedVolumes(requested) {
+		field, size := fmt.Sprintf("volumeSizes[%s]", name), requested[name]
+
+		volume, ok := known[name]
+		if !ok {
+			errors.Add(field, size, "is not a volume of version %s", instResource.Version)
+			continue
+		}
+
+		validateVolumeSize(errors, field, size, p[name])
+		if size < volume.Size {
+			errors.Add(field, size, "cannot be smaller than the current size of %d GiB", volume.Size)
+		}
+	}
+
+	if len(*errors) > 0 {
+		return
+	}
+
+	for name, size := range requested {
+		known[name].Size = size
+	}
+}
+
+func sortedVolumes(sizes map[string]int64) []string {
+	var volumes = make([]string, 0, len(sizes))
+	for volume := range sizes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	return volumes
+}
+
+// withVolumeSource returns volumeSpec with the data source its volume is created from. Volumes with a
+// snapshot of their own in details, as recorded on restored instances, are created from it; the others
+// from the data source of instance.
+func withVolumeSource(volumeSpec spec.VolumeSpec, instance *entity.Instance, details *InstanceDetails) spec.VolumeSpec {
+	if snapshot, ok := details.VolumeSources[volumeSpec.Volume]; ok {
+		volumeSpec.SourceName = snapshot
+		volumeSpec.VolumeDataSource = false
+		volumeSpec.SnapshotDataSource = true
+		return volumeSpec
+	}
+
+	volumeSpec.SourceName = instance.DataSource
+	volumeSpec.VolumeDataSource = instance.DataSourceType == ztypes.VolumeDataSource
+	volumeSpec.SnapshotDataSource = instance.DataSourceType == ztypes.SnapshotDataSource
+	return volumeSpec
+}
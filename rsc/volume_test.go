@@ -0,0 +1,88 @@
+package rsc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/spec"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+func sampleVolumeConfig() *config.VersionedResourceConfig {
+	return &config.VersionedResourceConfig{
+		Version: "v1",
+		Volumes: []string{"zcash-data", "zcash-params"},
+	}
+}
+
+func sampleVolumePolicy() VolumePolicy {
+	policy := DefaultVolumePolicy()
+	policy["zcash-data"] = VolumeSize{Default: 20, Min: 5, Max: 1000}
+	return policy
+}
+
+func Test_VolumeSizes(t *testing.T) {
+	var errors ValidationErrors
+	policy := sampleVolumePolicy()
+	sizes := policy.volumeSizes(&errors, sampleVolumeConfig(), nil)
+	assert.NoError(t, errors.Err())
+	assert.Equal(t, map[string]int64{"zcash-data": 20, "zcash-params": 3}, sizes)
+
+	sizes = policy.volumeSizes(&errors, sampleVolumeConfig(), map[string]int64{"zcash-data": 500})
+	assert.NoError(t, errors.Err())
+	assert.EqualValues(t, 500, sizes["zcash-data"])
+
+	policy.volumeSizes(&errors, sampleVolumeConfig(), map[string]int64{"zcash-data": 2000, "zcash-params": 0, "lwd-data": 10})
+	assert.Len(t, errors, 3)
+	assert.Equal(t, "volumeSizes[zcash-data]", errors[0].Field)
+	assert.Equal(t, "volumeSizes[zcash-params]", errors[1].Field)
+	assert.Equal(t, "volumeSizes[lwd-data]", errors[2].Field)
+}
+
+func Test_ResizeVolumes(t *testing.T) {
+	data := entity.DataVolume{Name: "zcash-data-node1", Volume: "zcash-data", Size: 20}
+	params := entity.DataVolume{Name: "zcash-params-node1", Volume: "zcash-params", Size: 3}
+
+	var errors ValidationErrors
+	policy := sampleVolumePolicy()
+	policy.resizeVolumes(&errors, sampleVolumeConfig(), map[string]int64{"zcash-data": 10, "zcash-params": 4}, &data, &params)
+	assert.Len(t, errors, 1)
+	assert.EqualValues(t, 20, data.Size)
+	assert.EqualValues(t, 3, params.Size)
+
+	errors = nil
+	policy.resizeVolumes(&errors, sampleVolumeConfig(), map[string]int64{"zcash-data": 100}, &data, &params)
+	assert.NoError(t, errors.Err())
+	assert.EqualValues(t, 100, data.Size)
+	assert.EqualValues(t, 3, params.Size)
+}
+
+func Test_VolumeSizesWithoutDefault(t *testing.T) {
+	var errors ValidationErrors
+	policy := VolumePolicy{"zcash-data": {Default: 10}}
+
+	sizes := policy.volumeSizes(&errors, sampleVolumeConfig(), map[string]int64{"zcash-params": 4})
+	assert.NoError(t, errors.Err())
+	assert.Equal(t, map[string]int64{"zcash-data": 10, "zcash-params": 4}, sizes)
+
+	policy.volumeSizes(&errors, sampleVolumeConfig(), nil)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "volumeSizes[zcash-params]", errors[0].Field)
+}
+
+func Test_WithVolumeSource(t *testing.T) {
+	instance := entity.Instance{Name: "node1", DataSourceType: ztypes.VolumeDataSource, DataSource: "zcash-data-node0"}
+	details := InstanceDetails{VolumeSources: map[string]string{"zcash-params": "node0-params-1"}}
+
+	data := withVolumeSource(spec.VolumeSpec{Volume: "zcash-data"}, &instance, &details)
+	assert.Equal(t, "zcash-data-node0", data.SourceName)
+	assert.True(t, data.VolumeDataSource)
+	assert.False(t, data.SnapshotDataSource)
+
+	params := withVolumeSource(spec.VolumeSpec{Volume: "zcash-params"}, &instance, &details)
+	assert.Equal(t, "node0-params-1", params.SourceName)
+	assert.False(t, params.VolumeDataSource)
+	assert.True(t, params.SnapshotDataSource)
+}
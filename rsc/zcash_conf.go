@@ -0,0 +1,420 @@
+package rsc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+type ZcashConfOptionType string
+
+const (
+	BoolOption   ZcashConfOptionType = "bool"
+	IntOption    ZcashConfOptionType = "int"
+	StringOption ZcashConfOptionType = "string"
+	ListOption   ZcashConfOptionType = "list"
+)
+
+// ZcashConfOption describes a zcash.conf setting that instance requests may set.
+type ZcashConfOption struct {
+	Type ZcashConfOptionType
+
+	// Min and Max bound the value of integer options.
+	Min int64
+	Max int64
+
+	// Values lists the values accepted by string and list options. When it is empty, values must match
+	// Pattern if there is one.
+	Values  []string
+	Pattern *regexp.Regexp
+
+	// Networks restricts the option to some networks. The option is allowed on every network when it is empty.
+	Networks []ztypes.NetworkType
+
+	// MinVersion is the first zcashd release that supports the option.
+	MinVersion string
+
+	// Requires lists the options that must be enabled along with this one, and TransactionIndex whether
+	// the instance must keep a transaction index.
+	Requires         []string
+	TransactionIndex bool
+}
+
+var (
+	peerAddressPattern  = regexp.MustCompile(`^(\[[0-9A-Fa-f:.]+\]|[A-Za-z0-9]([-A-Za-z0-9.]*[A-Za-z0-9])?)(:[0-9]{1,5})?$`)
+	absolutePathPattern = regexp.MustCompile(`^/[-A-Za-z0-9._/]*$`)
+	versionPattern      = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)`)
+
+	// ZCASH_CONF_OPTIONS are the zcash.conf settings instance requests may set. Settings the platform
+	// manages, such as the network, ports, credentials and the transaction index, are not among them.
+	ZCASH_CONF_OPTIONS = map[string]ZcashConfOption{
+		"maxconnections":       {Type: IntOption, Min: 1, Max: 1000},
+		"dbcache":              {Type: IntOption, Min: 4, Max: 16384},
+		"rpcworkqueue":         {Type: IntOption, Min: 1, Max: 1024},
+		"experimentalfeatures": {Type: BoolOption},
+		"insightexplorer":      {Type: BoolOption, Requires: []string{"experimentalfeatures"}, TransactionIndex: true},
+		"lightwalletd":         {Type: BoolOption, MinVersion: "v4.0.0", Requires: []string{"experimentalfeatures"}, TransactionIndex: true},
+		"exportdir":            {Type: StringOption, Pattern: absolutePathPattern},
+		"disablewallet":        {Type: BoolOption},
+		"debug": {Type: ListOption, Values: []string{"addrman", "alert", "bench", "coindb", "db", "estimatefee",
+			"http", "libevent", "lock", "mempool", "mempoolrej", "net", "partitioncheck", "pow", "proxy", "prune",
+			"rand", "reindex", "rpc", "selectcoins", "tor", "zmq", "zrpc", "zrpcunsafe"}},
+		"addnode": {Type: ListOption, Pattern: peerAddressPattern},
+		// connect would keep regtest nodes from peering with the other nodes of their project
+		"connect": {Type: ListOption, Pattern: peerAddressPattern, Networks: []ztypes.NetworkType{ztypes.NetworkTypeMain, ztypes.NetworkTypeTest}},
+	}
+)
+
+// ZcashConf generates the zcash.conf for a zcash node instance.
+type ZcashConf struct {
+	network          ztypes.NetworkType
+	rpcPort          int32
+	peerPort         int32
+	transactionIndex bool
+	miner            bool
+	minerThreads     int32
+	minerAddress     string
+	options          map[string][]string
+	peers            []string
+	rpcAuth          []string
+}
+
+func NewZcashConf(network ztypes.NetworkType, transactionIndex, miner bool) *ZcashConf {
+	var conf = &ZcashConf{network: network, transactionIndex: transactionIndex, miner: miner}
+	if network == NetworkTypeRegtest {
+		conf.peerPort = zcashPeerPort(network)
+	}
+	return conf
+}
+
+func (c *ZcashConf) SetRPCPort(port int32) {
+	c.rpcPort = port
+}
+
+func (c *ZcashConf) SetPeerPort(port int32) {
+	c.peerPort = port
+}
+
+// SetMining sets the number of threads a miner runs and the address it pays rewards to. Rewards go to the
+// node wallet when address is empty.
+func (c *ZcashConf) SetMining(threads int32, address string) {
+	c.minerThreads = threads
+	c.minerAddress = address
+}
+
+// AddRPCAuth adds rpcauth entries, which let more credentials call the node besides the configured ones.
+func (c *ZcashConf) AddRPCAuth(entries ...string) {
+	c.rpcAuth = append(c.rpcAuth, entries...)
+}
+
+// SetOptions adds settings validated by zcashConfOptions.
+func (c *ZcashConf) SetOptions(options map[string][]string) {
+	c.options = options
+}
+
+// AddPeers adds an addnode entry for each peer that has none yet.
+func (c *ZcashConf) AddPeers(peers ...string) {
+	for _, peer := range peers {
+		if peer = strings.TrimSpace(peer); peer != "" && !c.hasPeer(peer) {
+			c.peers = append(c.peers, peer)
+		}
+	}
+}
+
+func (c *ZcashConf) hasPeer(peer string) bool {
+	for _, existing := range c.peers {
+		if existing == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the settings indented to fit the zcash.conf block of the ZCASH_CONF ConfigMap.
+func (c *ZcashConf) Value() string {
+	var lines []string
+	switch c.network {
+	case ztypes.NetworkTypeTest:
+		lines = append(lines, "testnet=1")
+	case NetworkTypeRegtest:
+		lines = append(lines, "regtest=1")
+	}
+
+	if c.peerPort > 0 {
+		lines = append(lines, "listen=1", fmt.Sprintf("port=%d", c.peerPort))
+	}
+	if c.rpcPort > 0 {
+		lines = append(lines, fmt.Sprintf("rpcport=%d", c.rpcPort))
+	}
+	for _, entry := range c.rpcAuth {
+		lines = append(lines, fmt.Sprintf("rpcauth=%s", entry))
+	}
+
+	if c.transactionIndex {
+		lines = append(lines, "txindex=1")
+	}
+	if c.miner {
+		lines = append(lines, "gen=1", fmt.Sprintf("genproclimit=%d", minerThreads(c.minerThreads)))
+		if len(c.minerAddress) > 0 {
+			lines = append(lines, fmt.Sprintf("mineraddress=%s", c.minerAddress), "minetolocalwallet=0")
+		}
+	}
+
+	if c.network == NetworkTypeRegtest {
+		for _, upgrade := range REGTEST_UPGRADES {
+			lines = append(lines, fmt.Sprintf("nuparams=%s:1", upgrade))
+		}
+	}
+
+	for _, name := range sortedOptions(c.options) {
+		for _, value := range c.options[name] {
+			lines = append(lines, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+
+	for _, peer := range c.peers {
+		lines = append(lines, fmt.Sprintf("addnode=%s", peer))
+	}
+
+	return "    " + strings.Join(lines, "\n    ")
+}
+
+// zcashConfOptions validates the zcash.conf options requested for a node running nodeVersion on network.
+// It returns them as recorded on the instance: the values of each option as they are written to the file.
+func zcashConfOptions(errors *ValidationErrors, network ztypes.NetworkType, nodeVersion string, transactionIndex bool, requested map[string]interface{}) map[string][]string {
+	if len(requested) == 0 {
+		return nil
+	}
+
+	var names = make([]string, 0, len(requested))
+	for name := range requested {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var options = make(map[string][]string, len(requested))
+	for _, name := range names {
+		field, value := fmt.Sprintf("confOptions[%s]", name), requested[name]
+
+		option, ok := ZCASH_CONF_OPTIONS[name]
+		if !ok {
+			errors.Add(field, value, "is not a supported zcash.conf option")
+			continue
+		}
+
+		if !option.allowedOn(network) {
+			errors.Add(field, value, "is not allowed on %s", network)
+			continue
+		}
+
+		if len(option.MinVersion) > 0 && compareVersions(nodeVersion, option.MinVersion) < 0 {
+			errors.Add(field, value, "requires zcashd %s or later", option.MinVersion)
+			continue
+		}
+
+		if values, ok := option.values(errors, field, value); ok {
+			options[name] = values
+		}
+	}
+
+	for _, name := range sortedOptions(options) {
+		if !optionEnabled(options, name) {
+			continue
+		}
+
+		field, option := fmt.Sprintf("confOptions[%s]", name), ZCASH_CONF_OPTIONS[name]
+		for _, required := range option.Requires {
+			if !optionEnabled(options, required) {
+				errors.Add(field, requested[name], "requires %s to be enabled", required)
+			}
+		}
+		if option.TransactionIndex && !transactionIndex {
+			errors.Add(field, requested[name], "requires the transaction index")
+		}
+	}
+
+	return options
+}
+
+func (o ZcashConfOption) allowedOn(network ztypes.NetworkType) bool {
+	if len(o.Networks) == 0 {
+		return true
+	}
+	for _, allowed := range o.Networks {
+		if allowed == network {
+			return true
+		}
+	}
+	return false
+}
+
+// values converts a value decoded from JSON to the values written to zcash.conf.
+func (o ZcashConfOption) values(errors *ValidationErrors, field string, value interface{}) ([]string, bool) {
+	switch o.Type {
+	case BoolOption:
+		enabled, ok := value.(bool)
+		if !ok {
+			errors.Add(field, value, "must be a boolean")
+			return nil, false
+		}
+		if enabled {
+			return []string{"1"}, true
+		}
+		return []string{"0"}, true
+
+	case IntOption:
+		number, ok := integerValue(value)
+		if !ok {
+			errors.Add(field, value, "must be an integer")
+			return nil, false
+		}
+		if number < o.Min || number > o.Max {
+			errors.Add(field, value, "must be between %d and %d", o.Min, o.Max)
+			return nil, false
+		}
+		return []string{strconv.FormatInt(number, 10)}, true
+
+	case StringOption:
+		text, ok := value.(string)
+		if !ok {
+			errors.Add(field, value, "must be a string")
+			return nil, false
+		}
+		if !o.accepts(text) {
+			errors.Add(field, value, "is not a valid value")
+			return nil, false
+		}
+		return []string{text}, true
+
+	case ListOption:
+		items, ok := stringList(value)
+		if !ok || len(items) == 0 {
+			errors.Add(field, value, "must be a non-empty list of strings")
+			return nil, false
+		}
+		for _, item := range items {
+			if !o.accepts(item) {
+				errors.Add(field, value, "%q is not a valid value", item)
+				return nil, false
+			}
+		}
+		return items, true
+	}
+
+	errors.Add(field, value, "has an unknown type")
+	return nil, false
+}
+
+// accepts reports whether value can be written as is. Line breaks are never accepted, since they would
+// let a value add settings of its own.
+func (o ZcashConfOption) accepts(value string) bool {
+	if len(value) == 0 || strings.ContainsAny(value, "\r\n") {
+		return false
+	}
+
+	if len(o.Values) > 0 {
+		for _, accepted := range o.Values {
+			if value == accepted {
+				return true
+			}
+		}
+		return false
+	}
+
+	return o.Pattern == nil || o.Pattern.MatchString(value)
+}
+
+func integerValue(value interface{}) (int64, bool) {
+	switch number := value.(type) {
+	case int:
+		return int64(number), true
+	case int32:
+		return int64(number), true
+	case int64:
+		return number, true
+	case float64:
+		if number != math.Trunc(number) || math.Abs(number) > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(number), true
+	case json.Number:
+		n, err := number.Int64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func stringList(value interface{}) ([]string, bool) {
+	switch list := value.(type) {
+	case []string:
+		return list, true
+	case []interface{}:
+		var items = make([]string, len(list))
+		for index, item := range list {
+			text, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items[index] = text
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+func optionEnabled(options map[string][]string, name string) bool {
+	values, ok := options[name]
+	return ok && !(len(values) == 1 && values[0] == "0")
+}
+
+func sortedOptions(options map[string][]string) []string {
+	var names = make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compareVersions compares release versions such as v4.3.0. Versions that cannot be parsed are older
+// than any other.
+func compareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for index := range va {
+		if va[index] != vb[index] {
+			if va[index] < vb[index] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+	match := versionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return parsed, false
+	}
+	for index := range parsed {
+		parsed[index], _ = strconv.Atoi(match[index+1])
+	}
+	return parsed, true
+}
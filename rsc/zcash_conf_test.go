@@ -0,0 +1,106 @@
+package rsc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+func Test_ZcashConfValue(t *testing.T) {
+	conf := NewZcashConf(ztypes.NetworkTypeTest, true, false)
+	conf.SetRPCPort(18232)
+	conf.SetOptions(map[string][]string{"maxconnections": {"16"}, "debug": {"net", "rpc"}})
+
+	assert.Equal(t, "    testnet=1\n    rpcport=18232\n    txindex=1\n    debug=net\n    debug=rpc\n    maxconnections=16", conf.Value())
+}
+
+func Test_ZcashConfMining(t *testing.T) {
+	conf := NewZcashConf(ztypes.NetworkTypeTest, false, true)
+	assert.Contains(t, conf.Value(), "    gen=1\n    genproclimit=1")
+	assert.NotContains(t, conf.Value(), "mineraddress")
+
+	conf.SetMining(4, "tmRMquNGL6CJMRjkH6Ekv7Dj6E3QNGW6jK4")
+	assert.Contains(t, conf.Value(), "    genproclimit=4\n    mineraddress=tmRMquNGL6CJMRjkH6Ekv7Dj6E3QNGW6jK4\n    minetolocalwallet=0")
+}
+
+func Test_RegtestConfValue(t *testing.T) {
+	conf := NewZcashConf(NetworkTypeRegtest, true, false)
+	conf.SetRPCPort(18232)
+	conf.AddPeers("zcashd-svc-node2.project.svc.cluster.local:18344", " ")
+	conf.AddPeers("zcashd-svc-node2.project.svc.cluster.local:18344")
+
+	value := conf.Value()
+	assert.Contains(t, value, "    regtest=1\n")
+	assert.Contains(t, value, "    port=18344\n")
+	assert.Contains(t, value, "    rpcport=18232\n")
+	assert.Contains(t, value, "    txindex=1\n")
+	assert.Contains(t, value, "    nuparams=e9ff75a6:1\n")
+	assert.Contains(t, value, "    addnode=zcashd-svc-node2.project.svc.cluster.local:18344")
+	assert.NotContains(t, value, "gen=1")
+	assert.Equal(t, 1, len(conf.peers))
+}
+
+func Test_ZcashConfOptions(t *testing.T) {
+	var requested map[string]interface{}
+	err := json.Unmarshal([]byte(`{"maxconnections": 16, "dbcache": 1024, "experimentalfeatures": true,
+		"lightwalletd": true, "disablewallet": false, "exportdir": "/srv/export", "debug": ["net", "zrpc"],
+		"addnode": ["node.example.com:8233"]}`), &requested)
+	assert.NoError(t, err)
+
+	var errors ValidationErrors
+	options := zcashConfOptions(&errors, ztypes.NetworkTypeMain, "v4.3.0", true, requested)
+	assert.NoError(t, errors.Err())
+	assert.Equal(t, map[string][]string{
+		"maxconnections":       {"16"},
+		"dbcache":              {"1024"},
+		"experimentalfeatures": {"1"},
+		"lightwalletd":         {"1"},
+		"disablewallet":        {"0"},
+		"exportdir":            {"/srv/export"},
+		"debug":                {"net", "zrpc"},
+		"addnode":              {"node.example.com:8233"},
+	}, options)
+
+	assert.Nil(t, zcashConfOptions(&errors, ztypes.NetworkTypeMain, "v4.3.0", false, nil))
+}
+
+func Test_ZcashConfOptionsInvalid(t *testing.T) {
+	var errors ValidationErrors
+	zcashConfOptions(&errors, NetworkTypeRegtest, "v3.1.0", false, map[string]interface{}{
+		"rpcpassword":     "secret",
+		"maxconnections":  2000,
+		"dbcache":         "large",
+		"rpcworkqueue":    1.5,
+		"exportdir":       "/srv/export\nrpcallowip=0.0.0.0/0",
+		"debug":           []interface{}{"net", "wallet"},
+		"connect":         []string{"node.example.com"},
+		"lightwalletd":    true,
+		"insightexplorer": true,
+	})
+
+	var fields []string
+	for _, err := range errors {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{
+		"confOptions[connect]",
+		"confOptions[dbcache]",
+		"confOptions[debug]",
+		"confOptions[exportdir]",
+		"confOptions[lightwalletd]",
+		"confOptions[maxconnections]",
+		"confOptions[rpcpassword]",
+		"confOptions[rpcworkqueue]",
+		"confOptions[insightexplorer]",
+		"confOptions[insightexplorer]",
+	}, fields)
+}
+
+func Test_CompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("v4.0.0", "4.0.0"))
+	assert.Equal(t, 1, compareVersions("v4.10.0", "v4.9.2"))
+	assert.Equal(t, -1, compareVersions("v3.1.0", "v4.0.0"))
+	assert.Equal(t, -1, compareVersions("latest", "v4.0.0"))
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/zbitech/common/pkg/id"
-	"github.com/zbitech/common/pkg/model/k8s"
 	"github.com/zbitech/common/pkg/rctx"
 	"github.com/zbitech/common/pkg/utils"
 	"github.com/zbitech/mgr/internal/helper"
+	"github.com/zbitech/mgr/internal/ingress"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sync"
 	"text/template"
 	"time"
 
@@ -21,7 +21,6 @@ import (
 	"github.com/zbitech/common/pkg/model/object"
 	"github.com/zbitech/common/pkg/model/spec"
 	"github.com/zbitech/common/pkg/model/ztypes"
-	"github.com/zbitech/common/pkg/vars"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
@@ -35,27 +34,180 @@ var (
 			return utils.Base64EncodeString(creds)
 		},
 	}
+
+	ZCASH_TEMPLATES = TemplateContract{
+		deploymentOperation:       {"ZCASH_CONF", "ENVOY_CONF", "CREDENTIALS", "DEPLOYMENT", "SERVICE", "NETWORK_POLICY"},
+		startOperation:            {"DEPLOYMENT", "SERVICE"},
+		ingressOperation:          {"INGRESS", "INGRESS_STOPPED", "PROJECT_INGRESS"},
+		rotationOperation:         {"ZCASH_CONF", "CREDENTIALS"},
+		credentialExpiryOperation: {"ZCASH_CONF"},
+	}
 )
 
+// ZcashNodeDetails are the settings of a zcash node that entity.ZcashDetails does not hold.
+type ZcashNodeDetails struct {
+	BlockInterval int32               `json:"blockInterval,omitempty" bson:"blockInterval,omitempty"`
+	ConfOptions   map[string][]string `json:"confOptions,omitempty" bson:"confOptions,omitempty"`
+	AutoPeering   bool                `json:"autoPeering,omitempty" bson:"autoPeering,omitempty"`
+	MinerAddress  string              `json:"minerAddress,omitempty" bson:"minerAddress,omitempty"`
+	MinerThreads  int32               `json:"minerThreads,omitempty" bson:"minerThreads,omitempty"`
+	MethodGroups  []string            `json:"methodGroups,omitempty" bson:"methodGroups,omitempty"`
+
+	// RPCAuth holds the rpcauth entry of the current credentials, followed by those of replaced
+	// credentials the node accepts until RPCAuthExpires.
+	RPCAuth        []string  `json:"rpcAuth,omitempty" bson:"rpcAuth,omitempty"`
+	RPCAuthExpires time.Time `json:"rpcAuthExpires,omitempty" bson:"rpcAuthExpires,omitempty"`
+
+	// credentials are set when the node is created and when its credentials are rotated, and only
+	// then is its credentials Secret rendered.
+	credentials rpcCredentials
+}
+
+// clone returns the details with slices and maps of their own.
+func (d ZcashNodeDetails) clone() ZcashNodeDetails {
+	if d.ConfOptions != nil {
+		var options = make(map[string][]string, len(d.ConfOptions))
+		for name, values := range d.ConfOptions {
+			options[name] = append([]string(nil), values...)
+		}
+		d.ConfOptions = options
+	}
+	d.MethodGroups = append([]string(nil), d.MethodGroups...)
+	d.RPCAuth = append([]string(nil), d.RPCAuth...)
+	return d
+}
+
+// ZcashInstance is a zcash node with the details recorded by this module.
+type ZcashInstance struct {
+	entity.ZcashInstance `bson:",inline"`
+	ZcashNodeDetails     `bson:",inline"`
+	InstanceDetails      `bson:",inline"`
+}
+
+// ZcashNodeInstanceRequest adds the settings of ZcashInstance to object.ZcashNodeInstanceRequest.
+type ZcashNodeInstanceRequest struct {
+	object.ZcashNodeInstanceRequest
+	InstanceRequestDetails
+	BlockInterval int32                  `json:"blockInterval,omitempty"`
+	ConfOptions   map[string]interface{} `json:"confOptions,omitempty"`
+	MinerAddress  string                 `json:"minerAddress,omitempty"`
+	MinerThreads  int32                  `json:"minerThreads,omitempty"`
+	MethodGroups  []string               `json:"methodGroups,omitempty"`
+}
+
 type ZcashInstanceResourceManager struct {
+	mu        sync.RWMutex
 	rscConfig *config.InstanceResourceConfig
+	templates map[string]*FileTemplate
+	opts      Options
 }
 
-func NewZcashInstanceResourceManager(instanceConfig *config.InstanceResourceConfig) (interfaces.InstanceResourceManagerIF, error) {
-	var mgr = &ZcashInstanceResourceManager{rscConfig: instanceConfig}
+func NewZcashInstanceResourceManager(instanceConfig *config.InstanceResourceConfig, opts Options) (interfaces.InstanceResourceManagerIF, error) {
+	rscConfig, templates, err := loadInstanceConfig(opts.Assets, instanceConfig, FUNCTIONS)
+	if err != nil {
+		logger.Errorf(rctx.CTX, "Failed to create manager: %s", err)
+		return nil, err
+	}
 
-	for _, cfg := range mgr.rscConfig.Versions {
-		if err := cfg.Init(vars.ASSET_PATH_DIRECTORY, FUNCTIONS); err != nil {
-			logger.Errorf(rctx.CTX, "Failed to create manager: %s", err)
-			return nil, err
-		}
+	return &ZcashInstanceResourceManager{rscConfig: rscConfig, templates: templates, opts: opts}, nil
+}
+
+func (z *ZcashInstanceResourceManager) config() *config.InstanceResourceConfig {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.rscConfig
+}
+
+func (z *ZcashInstanceResourceManager) fileTemplate(version string) *FileTemplate {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.templates[version]
+}
+
+func (z *ZcashInstanceResourceManager) PrepareReload(ctx context.Context, cfg *config.InstanceResourceConfig) (func(), error) {
+	rscConfig, templates, err := loadInstanceConfig(z.opts.Assets, cfg, FUNCTIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = z.verification().verify(rscConfig.Versions, templates).Err(); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		z.mu.Lock()
+		defer z.mu.Unlock()
+		z.rscConfig = rscConfig
+		z.templates = templates
+	}, nil
+}
+
+func (z *ZcashInstanceResourceManager) VerifyTemplates(ctx context.Context) TemplateReport {
+	z.mu.RLock()
+	versions, templates := z.rscConfig.Versions, z.templates
+	z.mu.RUnlock()
+
+	return z.verification().verify(versions, templates)
+}
+
+func (z *ZcashInstanceResourceManager) verification() templateVerification {
+	return templateVerification{
+		manager:   string(ztypes.InstanceTypeZCASH),
+		contract:  ZCASH_TEMPLATES,
+		fragments: map[string]bool{"INGRESS": true, "INGRESS_STOPPED": true, "PROJECT_INGRESS": true},
+		sample:    z.sampleSpec,
 	}
+}
+
+func (z *ZcashInstanceResourceManager) sampleSpec(_, version string, instResource *config.VersionedResourceConfig) interface{} {
+	instance := &entity.ZcashInstance{Instance: sampleInstance(ztypes.InstanceTypeZCASH, version)}
+	conf := NewZcashConf(instance.Network, true, false)
+
+	var nodeImage = instResource.GetImage("node")
+	if nodeImage != nil {
+		conf.SetRPCPort(nodeImage.Port)
+	}
+
+	// Versions without a probe image are reported when their instances are deployed.
+	probe, _ := z.opts.probeSpec(context.Background(), instResource, "127.0.0.1", z.config().Ports["service"])
+
+	return zcashInstanceSpec{ZcashNodeInstanceSpec: spec.ZcashNodeInstanceSpec{
+		InstanceSpec: spec.InstanceSpec{
+			Name:               instance.Name,
+			Project:            instance.Project,
+			Version:            version,
+			ServiceAccountName: helper.CreateServiceAccountName(instance.GetProject()),
+			Namespace:          instance.GetNamespace(),
+			Labels:             helper.CreateInstanceLabels(instance),
+			DomainName:         z.opts.Policy.Domain,
+			DomainSecret:       z.opts.Policy.CertName,
+			DataSourceType:     instance.DataSourceType},
+		Username:     "contract",
+		Password:     "contract",
+		ZcashConf:    conf.Value(),
+		ZcashImage:   imageURL(nodeImage),
+		MetricsImage: imageURL(instResource.GetImage("metrics")),
+		Port:         z.config().Ports["service"],
+		MetricsPort:  z.config().Ports["metrics"],
+		DataVolume:   "zcash-data",
+		ParamsVolume: "zcash-params",
+		Envoy:        z.opts.envoySpec(instance.GetNamespace(), z.config().Ports["envoy"]),
+	}, NetworkPolicy: z.opts.networkPolicySpec(), Resources: z.opts.Profiles.Profiles[ztypes.InstanceTypeZCASH][z.opts.Profiles.Default],
+		Probe: probe, Methods: sampleMethods(instResource), InitImage: imageURL(instResource.GetImage("init"))}
+}
 
-	return mgr, nil
+// volumePolicy returns the volume policy of instances of version.
+func (z *ZcashInstanceResourceManager) volumePolicy(version string) VolumePolicy {
+	return z.opts.volumePolicy(z.fileTemplate(version))
+}
+
+// profilePolicy returns the profile policy of instances of version.
+func (z *ZcashInstanceResourceManager) profilePolicy(version string) ProfilePolicy {
+	return z.opts.profilePolicy(ztypes.InstanceTypeZCASH, z.fileTemplate(version))
 }
 
 func (z *ZcashInstanceResourceManager) GetInstanceResources(version string) (*config.VersionedResourceConfig, bool) {
-	resource, ok := z.rscConfig.Versions[version]
+	resource, ok := z.config().Versions[version]
 	return resource, ok
 }
 
@@ -66,7 +218,7 @@ func (z *ZcashInstanceResourceManager) CreateInstanceRequest(ctx context.Context
 		return nil, errs.ErrMarshalFailed
 	}
 
-	var zcashReq object.ZcashNodeInstanceRequest
+	var zcashReq ZcashNodeInstanceRequest
 	if err := json.Unmarshal(jsonStr, &zcashReq); err != nil {
 		logger.Errorf(ctx, "Failed to unmarshal request - %s", err)
 		return nil, errs.ErrMarshalFailed
@@ -83,104 +235,262 @@ func (z *ZcashInstanceResourceManager) CreateInstance(ctx context.Context, proje
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	zcashRequest := request.(object.ZcashNodeInstanceRequest)
+	zcashRequest := request.(ZcashNodeInstanceRequest)
+
+	settings, err := z.opts.projectSettings(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var errors ValidationErrors
+	confOptions := validateZcashRequest(&errors, project, settings, instResource, zcashRequest)
+	sizes := z.volumePolicy(zcashRequest.GetVersion()).volumeSizes(&errors, instResource, zcashRequest.VolumeSizes)
+	profile := z.profilePolicy(zcashRequest.GetVersion()).profileName(&errors, ztypes.InstanceTypeZCASH, zcashRequest.Profile)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Zcash instance request %s is invalid - %s", zcashRequest.GetName(), err)
+		return nil, err
+	}
+
 	dataVolume := instResource.Volumes[0]
 	paramsVolume := instResource.Volumes[1]
 
-	return &entity.ZcashInstance{
-		Instance: entity.Instance{
-			Project:        project.GetName(),
-			Name:           zcashRequest.GetName(),
-			Version:        zcashRequest.GetVersion(),
-			Network:        project.GetNetwork(),
-			Description:    zcashRequest.Description,
-			Owner:          project.GetOwner(),
-			Status:         "New",
-			Timestamp:      time.Now(),
-			DataSourceType: zcashRequest.GetDataSourceType(),
-			DataSource:     zcashRequest.GetDataSource(),
-			InstanceType:   zcashRequest.GetInstanceType(),
-			Action:         "created",
-			ActionTime:     time.Now(),
-			Age:            "",
+	zcash := ZcashInstance{
+		ZcashInstance: entity.ZcashInstance{
+			Instance: entity.Instance{
+				Project:        project.GetName(),
+				Name:           zcashRequest.GetName(),
+				Version:        zcashRequest.GetVersion(),
+				Network:        project.GetNetwork(),
+				Description:    zcashRequest.Description,
+				Owner:          project.GetOwner(),
+				Status:         "New",
+				Timestamp:      z.opts.now(),
+				DataSourceType: zcashRequest.GetDataSourceType(),
+				DataSource:     zcashRequest.GetDataSource(),
+				InstanceType:   zcashRequest.GetInstanceType(),
+				Action:         "created",
+				ActionTime:     z.opts.now(),
+				Age:            "",
+			},
+			ZcashDetails: entity.ZcashDetails{
+				TransactionIndex: zcashRequest.TransactionIndex,
+				Miner:            zcashRequest.Miner,
+				Peers:            zcashRequest.Peers,
+				DataVolume:       entity.DataVolume{Name: dataVolume + "-" + zcashRequest.Name, Size: sizes[dataVolume], Volume: dataVolume},
+				ParamsVolume:     entity.DataVolume{Name: paramsVolume + "-" + zcashRequest.Name, Size: sizes[paramsVolume], Volume: paramsVolume},
+			},
 		},
-		ZcashDetails: entity.ZcashDetails{
-			TransactionIndex: zcashRequest.TransactionIndex,
-			Miner:            zcashRequest.Miner,
-			Peers:            zcashRequest.Peers,
-			DataVolume:       entity.DataVolume{Name: dataVolume + "-" + zcashRequest.Name, Size: 10, Volume: dataVolume},
-			ParamsVolume:     entity.DataVolume{Name: paramsVolume + "-" + zcashRequest.Name, Size: 3, Volume: paramsVolume},
+		ZcashNodeDetails: ZcashNodeDetails{
+			BlockInterval: zcashRequest.BlockInterval,
+			ConfOptions:   confOptions,
+			AutoPeering:   settings.AutoPeering,
+			MinerAddress:  zcashRequest.MinerAddress,
+			MinerThreads:  zcashRequest.MinerThreads,
+			MethodGroups:  zcashRequest.MethodGroups,
 		},
-	}, nil
+		InstanceDetails: InstanceDetails{Profile: profile},
+	}
 
-	//	return &zcash, nil
+	if err = z.opts.newCredentials(&zcash); err != nil {
+		logger.Errorf(ctx, "Credentials of zcash instance %s not generated - %s", zcash.Name, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	return &zcash, nil
 }
 
 func (z *ZcashInstanceResourceManager) UpdateInstance(ctx context.Context, project *entity.Project, instance entity.InstanceIF, request object.InstanceRequestIF) error {
 
-	zcashRequest := request.(object.ZcashNodeInstanceRequest)
-	zcash := instance.(*entity.ZcashInstance)
+	zcashRequest := request.(ZcashNodeInstanceRequest)
+	zcash, ok := instance.(*ZcashInstance)
+	if !ok {
+		logger.Errorf(ctx, "Zcash instance %s was not created by this manager", instance.GetName())
+		return errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := z.GetInstanceResources(zcash.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
+		return errs.ErrInstanceResourceFailed
+	}
+
+	settings, err := z.opts.projectSettings(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	var errors ValidationErrors
+	confOptions := validateZcashRequest(&errors, project, settings, instResource, zcashRequest)
+	if len(zcashRequest.Profile) > 0 {
+		z.profilePolicy(zcash.Version).profileName(&errors, ztypes.InstanceTypeZCASH, zcashRequest.Profile)
+	}
+	z.volumePolicy(zcash.Version).resizeVolumes(&errors, instResource, zcashRequest.VolumeSizes, &zcash.DataVolume, &zcash.ParamsVolume)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Zcash instance request %s is invalid - %s", zcashRequest.GetName(), err)
+		return err
+	}
+
+	if len(zcashRequest.Profile) > 0 {
+		zcash.Profile = zcashRequest.Profile
+	}
 
 	zcash.Action = "updated"
-	zcash.ActionTime = time.Now()
+	zcash.ActionTime = z.opts.now()
 	zcash.Description = zcashRequest.Description
 	//detail := instance.InstanceDetail.(entity.ZcashInstanceDetail)
 	zcash.Miner = zcashRequest.Miner
+	zcash.MinerAddress = zcashRequest.MinerAddress
+	zcash.MinerThreads = zcashRequest.MinerThreads
 	zcash.TransactionIndex = zcashRequest.TransactionIndex
 	zcash.Peers = zcashRequest.Peers
+	zcash.AutoPeering = settings.AutoPeering
+	zcash.BlockInterval = zcashRequest.BlockInterval
+	zcash.ConfOptions = confOptions
+	zcash.MethodGroups = zcashRequest.MethodGroups
 
 	return nil
 }
 
-func (z *ZcashInstanceResourceManager) CreateDeploymentResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+// validateZcashRequest validates request and returns the zcash.conf options it sets.
+func validateZcashRequest(errors *ValidationErrors, project *entity.Project, settings ProjectSettings, instResource *config.VersionedResourceConfig, request ZcashNodeInstanceRequest) map[string][]string {
+	validateBlockInterval(errors, project.GetNetwork(), request.BlockInterval)
+	validatePeers(errors, request.Peers)
+	validateMining(errors, project, settings, request)
+	validateMethodGroups(errors, instResource, request.MethodGroups)
+	return zcashConfOptions(errors, project.GetNetwork(), imageVersion(instResource.GetImage("node")), request.TransactionIndex, request.ConfOptions)
+}
 
-	zcash := instance.(*entity.ZcashInstance)
-	instResource, ok := z.GetInstanceResources(zcash.Version)
-	if !ok {
-		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
-		return nil, errs.ErrInstanceResourceFailed
+// zcashConf returns the zcash.conf of zcash, with an addnode entry for each of its peers. Regtest nodes,
+// and nodes of projects with auto-peering, are also peered with the other zcash nodes of their project.
+func (z *ZcashInstanceResourceManager) zcashConf(ctx context.Context, zcash *ZcashInstance, rpcPort int32) (string, error) {
+	conf := NewZcashConf(zcash.Network, zcash.TransactionIndex, zcash.Miner)
+	conf.SetRPCPort(rpcPort)
+	conf.AddRPCAuth(previousCredentials(zcash, z.opts.now())...)
+	conf.SetMining(zcash.MinerThreads, zcash.MinerAddress)
+	conf.SetOptions(zcash.ConfOptions)
+	conf.AddPeers(zcash.Peers...)
+
+	if peersWithSiblings(zcash) {
+		peers, err := z.siblingPeers(ctx, zcash)
+		if err != nil {
+			return "", err
+		}
+		conf.SetPeerPort(zcashPeerPort(zcash.Network))
+		conf.AddPeers(peers...)
 	}
 
+	return conf.Value(), nil
+}
+
+func (z *ZcashInstanceResourceManager) instanceSpec(ctx context.Context, zcash *ZcashInstance, zcashSpec spec.ZcashNodeInstanceSpec) (zcashInstanceSpec, error) {
+	resources, err := z.profilePolicy(zcash.Version).profile(ctx, ztypes.InstanceTypeZCASH, zcash.Profile)
+	if err != nil {
+		return zcashInstanceSpec{}, err
+	}
+	if zcash.Miner {
+		resources = minerResources(resources, zcash.MinerThreads)
+	}
+
+	var instanceSpec = zcashInstanceSpec{ZcashNodeInstanceSpec: zcashSpec, NetworkPolicy: z.opts.networkPolicySpec(), Resources: resources}
+	if peersWithSiblings(zcash) {
+		instanceSpec.PeerPort = zcashPeerPort(zcash.Network)
+	}
+	if zcash.Network == NetworkTypeRegtest {
+		instanceSpec.BlockInterval = zcash.BlockInterval
+	}
+	return instanceSpec, nil
+}
+
+// deploymentSpec returns the spec the templates of zcash are rendered with. Its username and password are
+// only set when zcash has credentials that have not been rendered yet.
+func (z *ZcashInstanceResourceManager) deploymentSpec(ctx context.Context, zcash *ZcashInstance, instResource *config.VersionedResourceConfig) (zcashInstanceSpec, error) {
 	nodeImage := instResource.GetImage("node")
 	metricsImage := instResource.GetImage("metrics")
 	if nodeImage == nil || metricsImage == nil {
-		return nil, errs.ErrInstanceResourceFailed
+		return zcashInstanceSpec{}, errs.ErrInstanceResourceFailed
+	}
+
+	initImage := instResource.GetImage("init")
+	if initImage == nil {
+		logger.Errorf(ctx, "Instance version %s does not define the init image", instResource.Version)
+		return zcashInstanceSpec{}, errs.ErrInstanceResourceFailed
 	}
 
-	conf := object.NewZcashConf(zcash.Network, zcash.TransactionIndex, zcash.Miner)
-	conf.SetPort(nodeImage.Port)
+	if len(zcash.RPCAuth) == 0 {
+		logger.Errorf(ctx, "Zcash instance %s has no credentials", zcash.Name)
+		return zcashInstanceSpec{}, errs.ErrInstanceDataFailed
+	}
+
+	zcashConf, err := z.zcashConf(ctx, zcash, nodeImage.Port)
+	if err != nil {
+		return zcashInstanceSpec{}, err
+	}
 
 	zcashSpec := spec.ZcashNodeInstanceSpec{
 		InstanceSpec: spec.InstanceSpec{
 			Name:               zcash.Name,
 			Project:            zcash.Project,
 			Version:            zcash.Version,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
+			ServiceAccountName: helper.CreateServiceAccountName(zcash.GetProject()),
 			Namespace:          zcash.GetNamespace(),
 			Labels:             helper.CreateInstanceLabels(zcash),
-			DomainName:         vars.AppConfig.Policy.Domain,
-			DomainSecret:       vars.AppConfig.Policy.CertName,
+			DomainName:         z.opts.Policy.Domain,
+			DomainSecret:       z.opts.Policy.CertName,
 			DataSourceType:     zcash.DataSourceType,
 			DataSource:         zcash.DataSource},
-		Username:     id.GenerateUserName(),
-		Password:     id.GenerateSecurePassword(),
-		ZcashConf:    conf.Value(),
+		Username:     zcash.credentials.username,
+		Password:     zcash.credentials.password,
+		ZcashConf:    zcashConf,
 		ZcashImage:   nodeImage.URL,
 		MetricsImage: metricsImage.URL,
-		Port:         z.rscConfig.Ports["service"],
-		MetricsPort:  z.rscConfig.Ports["metrics"],
+		Port:         z.config().Ports["service"],
+		MetricsPort:  z.config().Ports["metrics"],
 		DataVolume:   zcash.DataVolume.Name,
 		ParamsVolume: zcash.ParamsVolume.Name,
-		Envoy:        helper.CreateEnvoySpec(z.rscConfig.Ports["envoy"]),
+		Envoy:        z.opts.envoySpec(zcash.GetNamespace(), z.config().Ports["envoy"]),
+	}
+
+	instanceSpec, err := z.instanceSpec(ctx, zcash, zcashSpec)
+	if err != nil {
+		return zcashInstanceSpec{}, err
+	}
+	instanceSpec.InitImage = initImage.URL
+
+	instanceSpec.Methods, err = allowedMethods(ctx, instResource, zcash.MethodGroups)
+	if err != nil {
+		return zcashInstanceSpec{}, err
+	}
+
+	instanceSpec.Probe, err = z.opts.probeSpec(ctx, instResource, "127.0.0.1", zcashSpec.Port)
+	if err != nil {
+		return zcashInstanceSpec{}, err
 	}
 
-	//	logger.Infof(ctx, "Creating deployment assets for: %s", utils.MarshalObject(zcashSpec))
+	return instanceSpec, nil
+}
+
+func (z *ZcashInstanceResourceManager) CreateDeploymentResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+
+	zcash, ok := asZcashInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := z.GetInstanceResources(zcash.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	instanceSpec, err := z.deploymentSpec(ctx, zcash, instResource)
+	if err != nil {
+		return nil, err
+	}
 
 	var specArr []string
-	var err error
 
-	fileTemplate := instResource.GetFileTemplate()
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"ZCASH_CONF", "ENVOY_CONF", "CREDENTIALS", "DEPLOYMENT", "SERVICE"}, zcashSpec)
+	fileTemplate := z.fileTemplate(zcash.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(deploymentTemplates(zcash), instanceSpec)
 	if err != nil {
 		logger.Errorf(ctx, "Zcash templates for version %s failed - %s", zcash.Version, err)
 		return nil, errs.ErrInstanceResourceFailed
@@ -192,20 +502,16 @@ func (z *ZcashInstanceResourceManager) CreateDeploymentResourceAssets(ctx contex
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	volumeDataSource := zcash.DataSourceType == ztypes.VolumeDataSource
-	snapshotDataSource := zcash.DataSourceType == ztypes.SnapshotDataSource
-	storageClass := vars.AppConfig.Policy.StorageClass
+	storageClass := z.opts.Policy.StorageClass
 
 	var volumeSpecs = []spec.VolumeSpec{
-		{Volume: zcash.DataVolume.Volume, VolumeName: zcash.DataVolume.Name, StorageClass: storageClass,
-			Namespace: zcash.GetNamespace(), SourceName: zcash.DataSource, VolumeDataSource: volumeDataSource,
-			SnapshotDataSource: snapshotDataSource, Size: zcash.DataVolume.Size, Labels: zcashSpec.Labels},
-		{Volume: zcash.ParamsVolume.Volume, VolumeName: zcash.ParamsVolume.Name, StorageClass: storageClass,
-			Namespace: zcash.GetNamespace(), SourceName: zcash.DataSource, VolumeDataSource: volumeDataSource,
-			SnapshotDataSource: snapshotDataSource, Size: zcash.ParamsVolume.Size, Labels: zcashSpec.Labels},
+		withVolumeSource(spec.VolumeSpec{Volume: zcash.DataVolume.Volume, VolumeName: zcash.DataVolume.Name, StorageClass: storageClass,
+			Namespace: zcash.GetNamespace(), Size: zcash.DataVolume.Size, Labels: instanceSpec.Labels}, &zcash.Instance, &zcash.InstanceDetails),
+		withVolumeSource(spec.VolumeSpec{Volume: zcash.ParamsVolume.Volume, VolumeName: zcash.ParamsVolume.Name, StorageClass: storageClass,
+			Namespace: zcash.GetNamespace(), Size: zcash.ParamsVolume.Size, Labels: instanceSpec.Labels}, &zcash.Instance, &zcash.InstanceDetails),
 	}
 
-	appRsc := vars.ManagerFactory.GetAppResourceManager(ctx)
+	appRsc := z.opts.appManager(ctx)
 	volumes, err := appRsc.CreateVolumeAsset(ctx, volumeSpecs...)
 	if err != nil {
 		logger.Errorf(ctx, "Zcash volume templates for version %s failed - %s", zcash.Version, err)
@@ -213,46 +519,42 @@ func (z *ZcashInstanceResourceManager) CreateDeploymentResourceAssets(ctx contex
 	}
 
 	objects = append(objects, volumes...)
-	return objects, nil
+	return applyInstanceMetadata(instance, objects), nil
+}
+
+// deploymentTemplates returns the deployment templates of zcash. The credentials Secret is only rendered
+// when zcash has new credentials, so that redeploying an instance keeps its existing Secret.
+func deploymentTemplates(zcash *ZcashInstance) []string {
+	var keys []string
+	for _, key := range ZCASH_TEMPLATES[deploymentOperation] {
+		if key != "CREDENTIALS" || len(zcash.credentials.password) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 func (z *ZcashInstanceResourceManager) CreateStartResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
-	zcash := instance.(*entity.ZcashInstance)
+	zcash, ok := asZcashInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
 	instResource, ok := z.GetInstanceResources(zcash.Version)
 	if !ok {
 		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	nodeImage := instResource.GetImage("node")
-	metricsImage := instResource.GetImage("metrics")
-	if nodeImage == nil || metricsImage == nil {
-		return nil, errs.ErrInstanceResourceFailed
+	instanceSpec, err := z.deploymentSpec(ctx, zcash, instResource)
+	if err != nil {
+		return nil, err
 	}
 
-	zcashSpec := spec.ZcashNodeInstanceSpec{
-		InstanceSpec: spec.InstanceSpec{
-			Name:               zcash.Name,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
-			Namespace:          zcash.GetNamespace(),
-			Labels:             helper.CreateInstanceLabels(zcash),
-			DomainName:         vars.AppConfig.Policy.Domain,
-			DomainSecret:       vars.AppConfig.Policy.CertName,
-			DataSourceType:     zcash.DataSourceType,
-			DataSource:         zcash.DataSource},
-		ZcashImage:   nodeImage.URL,
-		MetricsImage: metricsImage.URL,
-		Port:         z.rscConfig.Ports["service"],
-		MetricsPort:  z.rscConfig.Ports["metrics"],
-		DataVolume:   zcash.DataVolume.Name,
-		ParamsVolume: zcash.ParamsVolume.Name,
-		Envoy:        helper.CreateEnvoySpec(z.rscConfig.Ports["envoy"]),
-	}
 	var specArr []string
-	var err error
 
-	fileTemplate := instResource.GetFileTemplate()
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"DEPLOYMENT", "SERVICE"}, zcashSpec)
+	fileTemplate := z.fileTemplate(zcash.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(ZCASH_TEMPLATES[startOperation], instanceSpec)
 	if err != nil {
 		logger.Errorf(ctx, "Zcash templates for version %s failed - %s", zcash.Version, err)
 		return nil, errs.ErrInstanceResourceFailed
@@ -264,13 +566,17 @@ func (z *ZcashInstanceResourceManager) CreateStartResourceAssets(ctx context.Con
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	return objects, nil
+	return applyInstanceMetadata(instance, objects), nil
 }
 
 func (z *ZcashInstanceResourceManager) CreateIngressAsset(ctx context.Context, projIngress *unstructured.Unstructured, instance entity.InstanceIF, action ztypes.EventAction) (*unstructured.Unstructured, error) {
 
-	zcash := instance.(*entity.ZcashInstance)
-	instResource, ok := z.GetInstanceResources(zcash.Version)
+	zcash, ok := asZcashInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	_, ok = z.GetInstanceResources(zcash.Version)
 	if !ok {
 		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
 		return nil, errs.ErrInstanceResourceFailed
@@ -279,17 +585,19 @@ func (z *ZcashInstanceResourceManager) CreateIngressAsset(ctx context.Context, p
 	zcashSpec := spec.ZcashNodeInstanceSpec{
 		InstanceSpec: spec.InstanceSpec{
 			Name:               zcash.Name,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
+			Project:            zcash.Project,
+			Version:            zcash.Version,
+			ServiceAccountName: helper.CreateServiceAccountName(zcash.GetProject()),
 			Namespace:          zcash.GetNamespace(),
 			Labels:             helper.CreateInstanceLabels(zcash),
-			DomainName:         vars.AppConfig.Policy.Domain,
-			DomainSecret:       vars.AppConfig.Policy.CertName},
-		Envoy: helper.CreateEnvoySpec(z.rscConfig.Ports["envoy"]),
+			DomainName:         z.opts.Policy.Domain,
+			DomainSecret:       z.opts.Policy.CertName},
+		Envoy: z.opts.envoySpec(zcash.GetNamespace(), z.config().Ports["envoy"]),
 	}
 	var specObj string
 	var err error
 
-	fileTemplate := instResource.GetFileTemplate()
+	fileTemplate := z.fileTemplate(zcash.Version)
 	if action == ztypes.EventActionStopInstance {
 		specObj, err = fileTemplate.ExecuteTemplate("INGRESS_STOPPED", zcashSpec)
 	} else {
@@ -300,53 +608,21 @@ func (z *ZcashInstanceResourceManager) CreateIngressAsset(ctx context.Context, p
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	var route k8s.IngressRoute
-	if err = json.Unmarshal([]byte(specObj), &route); err != nil {
-		logger.Errorf(ctx, "Zcash route marshal failed - %s", err)
-		return nil, errs.ErrIngressResourceFailed
-	}
-	logger.Infof(ctx, "Route: %s", utils.MarshalObject(route))
-
-	//TODO - handle projIngress == nil - return error?
-	utils.RemoveResourceField(projIngress, "metadata.managedFields")
-	utils.RemoveResourceField(projIngress, "spec.status")
-
-	routeData := utils.MarshalObject(utils.GetResourceField(projIngress, "spec.routes"))
-	var routes []k8s.IngressRoute
-	if err = json.Unmarshal([]byte(routeData), &routes); err != nil {
-		logger.Errorf(ctx, "Error unmarshaling ingress routes - %s", err)
-	}
-	var updated = false
-	for index, r := range routes {
-		for _, condition := range r.Conditions {
-			logger.Infof(ctx, "Comparing %s and %s at index %d ...", condition.Prefix, route.Conditions[0].Prefix, index)
-			if condition.Prefix == route.Conditions[0].Prefix {
-				if action == ztypes.EventActionDelete {
-					routes = append(routes[:index], routes[index+1:]...)
-				} else {
-					routes = append(routes[:index], route)
-					routes = append(routes, routes[index+1:]...)
-				}
-				updated = true
-			}
-		}
-	}
-
-	if !updated {
-		routes = append(routes, route)
-	}
-	logger.Debugf(ctx, "Ingress routes: %s", utils.MarshalObject(routes))
-	utils.SetResourceField(projIngress, "spec.routes", routes)
-
-	return projIngress, nil
+	return reconcileIngress(ctx, projIngress, ingress.Route, specObj, action, func() (string, error) {
+		return fileTemplate.ExecuteTemplate("PROJECT_INGRESS", zcashSpec)
+	})
 }
 
 func (z *ZcashInstanceResourceManager) CreateSnapshotAssets(ctx context.Context, instance entity.InstanceIF, volume string) ([]*unstructured.Unstructured, error) {
 
 	var req object.SnapshotRequest
 
-	zcash := instance.(*entity.ZcashInstance)
-	appRsc := vars.ManagerFactory.GetAppResourceManager(ctx)
+	zcash, ok := asZcashInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	appRsc := z.opts.appManager(ctx)
 	req.Namespace = zcash.GetNamespace()
 	if volume == "zcash-data" {
 		req.VolumeName = zcash.DataVolume.Name
@@ -355,16 +631,24 @@ func (z *ZcashInstanceResourceManager) CreateSnapshotAssets(ctx context.Context,
 	}
 	req.Labels = helper.CreateInstanceLabels(zcash)
 
-	return appRsc.CreateSnapshotAsset(ctx, &req)
+	objects, err := appRsc.CreateSnapshotAsset(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
 }
 
 func (z *ZcashInstanceResourceManager) CreateSnapshotScheduleAssets(ctx context.Context, instance entity.InstanceIF, volume string, scheduleType ztypes.ZBIBackupScheduleType) ([]*unstructured.Unstructured, error) {
 
 	var req object.SnapshotScheduleRequest
 
-	zcash := instance.(*entity.ZcashInstance)
+	zcash, ok := asZcashInstance(instance)
+	if !ok {
+		return nil, errs.ErrInstanceDataFailed
+	}
 
-	appRsc := vars.ManagerFactory.GetAppResourceManager(ctx)
+	appRsc := z.opts.appManager(ctx)
 	req.Namespace = zcash.GetNamespace()
 	req.Schedule = scheduleType
 	if volume == "zcash-data" {
@@ -374,48 +658,122 @@ func (z *ZcashInstanceResourceManager) CreateSnapshotScheduleAssets(ctx context.
 	}
 	req.Labels = helper.CreateInstanceLabels(zcash)
 
-	return appRsc.CreateSnapshotScheduleAsset(ctx, &req)
+	objects, err := appRsc.CreateSnapshotScheduleAsset(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
 }
 
+// CreateRotationAssets gives the node new credentials and records them on instance, which should be saved
+// once the assets are applied. It is the only place credentials are replaced after the node is created.
 func (z *ZcashInstanceResourceManager) CreateRotationAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
-	zcash := instance.(*entity.ZcashInstance)
+	objects, commit, err := z.prepareRotation(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	commit()
+	return objects, nil
+}
+
+// prepareRotation renders the assets of new credentials for instance. The credentials are recorded on
+// instance when the returned function is called, so that callers can render the assets of dependent
+// instances first.
+func (z *ZcashInstanceResourceManager) prepareRotation(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, func(), error) {
+	zcash, ok := instance.(*ZcashInstance)
+	if !ok {
+		logger.Errorf(ctx, "Zcash instance %s was not created by this manager", instance.GetName())
+		return nil, nil, errs.ErrInstanceDataFailed
+	}
+
+	instResource, ok := z.GetInstanceResources(zcash.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
+		return nil, nil, errs.ErrInstanceResourceFailed
+	}
+
+	var rotated = *zcash
+	rotated.ZcashNodeDetails = zcash.ZcashNodeDetails.clone()
+	if err := z.opts.newCredentials(&rotated); err != nil {
+		logger.Errorf(ctx, "Credentials of zcash instance %s not generated - %s", zcash.Name, err)
+		return nil, nil, errs.ErrInstanceResourceFailed
+	}
+
+	objects, err := z.renderTemplates(ctx, &rotated, instResource, ZCASH_TEMPLATES[rotationOperation])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), func() {
+		zcash.ZcashNodeDetails = rotated.ZcashNodeDetails
+	}, nil
+}
+
+// CreateCredentialExpiryAssets renders the zcash.conf of instance without the rpcauth entries of replaced
+// credentials and drops the entries from instance, which should be saved once the assets are applied.
+// The entries are only rendered until RPCAuthExpires, but nothing renders the node when that time passes,
+// so callers run this once Options.CredentialOverlap has passed after a rotation. It returns no assets
+// while the replaced credentials are still accepted.
+func (z *ZcashInstanceResourceManager) CreateCredentialExpiryAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+	zcash, ok := instance.(*ZcashInstance)
+	if !ok {
+		logger.Errorf(ctx, "Zcash instance %s was not created by this manager", instance.GetName())
+		return nil, errs.ErrInstanceDataFailed
+	}
+
+	if len(zcash.RPCAuth) < 2 || z.opts.now().Before(zcash.RPCAuthExpires) {
+		return nil, nil
+	}
+
 	instResource, ok := z.GetInstanceResources(zcash.Version)
 	if !ok {
 		logger.Errorf(ctx, "Zcash resource not available for %s", zcash.Version)
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	zcashSpec := spec.ZcashNodeInstanceSpec{
-		InstanceSpec: spec.InstanceSpec{
-			Name:               zcash.Name,
-			ServiceAccountName: vars.AppConfig.Policy.ServiceAccount,
-			Namespace:          zcash.GetNamespace(),
-			Labels:             helper.CreateInstanceLabels(zcash),
-			DataSourceType:     zcash.DataSourceType,
-			DataSource:         zcash.DataSource},
-		Username: id.GenerateUserName(),
-		Password: id.GenerateSecurePassword(),
-		Envoy:    helper.CreateEnvoySpec(z.rscConfig.Ports["envoy"]),
+	var expired = *zcash
+	expired.ZcashNodeDetails = zcash.ZcashNodeDetails.clone()
+	expired.RPCAuth = expired.RPCAuth[:1]
+	expired.RPCAuthExpires = time.Time{}
+
+	objects, err := z.renderTemplates(ctx, &expired, instResource, ZCASH_TEMPLATES[credentialExpiryOperation])
+	if err != nil {
+		return nil, err
 	}
 
-	var specArr []string
-	var err error
+	zcash.ZcashNodeDetails = expired.ZcashNodeDetails
+	return applyInstanceMetadata(instance, objects), nil
+}
+
+// renderTemplates renders keys of the templates of zcash with its deployment spec.
+func (z *ZcashInstanceResourceManager) renderTemplates(ctx context.Context, zcash *ZcashInstance, instResource *config.VersionedResourceConfig, keys []string) ([]*unstructured.Unstructured, error) {
+	instanceSpec, err := z.deploymentSpec(ctx, zcash, instResource)
+	if err != nil {
+		return nil, err
+	}
 
-	fileTemplate := instResource.GetFileTemplate()
-	specArr, err = fileTemplate.ExecuteTemplates([]string{"ENVOY_CONF", "CREDENTIALS"}, zcashSpec)
+	fileTemplate := z.fileTemplate(zcash.Version)
+	specArr, err := fileTemplate.ExecuteTemplates(keys, instanceSpec)
 	if err != nil {
 		logger.Errorf(ctx, "Zcash templates for version %s failed - %s", zcash.Version, err)
 		return nil, errs.ErrInstanceResourceFailed
 	}
 
-	return helper.CreateYAMLObjects(specArr)
+	objects, err := helper.CreateYAMLObjects(specArr)
+	if err != nil {
+		logger.Errorf(ctx, "Zcash templates for version %s failed - %s", zcash.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+	return objects, nil
 }
 
 func (z *ZcashInstanceResourceManager) UnmarshalBSONDetails(ctx context.Context, value bson.Raw) (entity.InstanceIF, error) {
 
 	logger.Tracef(ctx, "Unmarshaling Zcash instance details ............. %s", value.String())
 
-	var zcash entity.ZcashInstance
+	var zcash ZcashInstance
 	if err := bson.Unmarshal(value, &zcash); err != nil {
 		return nil, err
 	}
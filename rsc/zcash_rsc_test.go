@@ -2,8 +2,11 @@ package rsc
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/zbitech/common/factory"
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/model/entity"
 	"github.com/zbitech/common/pkg/model/k8s"
 	"github.com/zbitech/common/pkg/model/object"
 	"github.com/zbitech/common/pkg/model/spec"
@@ -13,8 +16,10 @@ import (
 	"github.com/zbitech/fake/data"
 	"github.com/zbitech/fake/mgr/rsc"
 	"github.com/zbitech/fake/test"
+	"go.mongodb.org/mongo-driver/bson"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"testing"
+	"time"
 )
 
 func Test_NewZcashInstanceResourceManager(t *testing.T) {
@@ -25,7 +30,7 @@ func Test_NewZcashInstanceResourceManager(t *testing.T) {
 	assert.Truef(t, ok, "zcash resource not configured")
 	assert.NotNilf(t, zcashConfig, "Failed to get zcash resource")
 
-	zcashResource, err := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, err := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 	assert.NoErrorf(t, err, "Error creating zcash resource manager - %s", err)
 	assert.NotNilf(t, zcashResource, "Failed to create zcash resource manager")
 }
@@ -38,7 +43,7 @@ func Test_GetZcashInstanceResources(t *testing.T) {
 	assert.Truef(t, ok, "zcash resource not configured")
 	assert.NotNilf(t, zcashConfig, "Failed to get zcash resource")
 
-	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 	v1Config, ok := zcashResource.GetInstanceResources("v1")
 	assert.Truef(t, ok, "zcash resource for v1 not configured")
 	assert.NotNilf(t, v1Config, "Failed to get zcash resource for v1")
@@ -49,10 +54,34 @@ func Test_CreateZcashInstance(t *testing.T) {
 	factory.InitProjectResourceConfig(ctx)
 
 	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 
+	instance, err := createZcashInstance(ctx, zcashResource)
+	assert.NoError(t, err)
+	assert.NotNil(t, instance)
+
+	zcash := instance.(*ZcashInstance)
+	assert.NotEmpty(t, zcash.credentials.password)
+	assert.Len(t, zcash.RPCAuth, 1)
+
+	value, err := bson.Marshal(zcash)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(value), zcash.credentials.password)
+}
+
+// objectKinds returns the kinds of objects.
+func objectKinds(objects []*unstructured.Unstructured) []string {
+	var kinds []string
+	for _, object := range objects {
+		kinds = append(kinds, object.GetKind())
+	}
+	return kinds
+}
+
+// createZcashInstance creates a zcash node like data.Instance1 with zcashResource.
+func createZcashInstance(ctx context.Context, zcashResource interfaces.InstanceResourceManagerIF) (entity.InstanceIF, error) {
 	var project = data.Project1
-	var request = object.ZcashNodeInstanceRequest{
+	var request = ZcashNodeInstanceRequest{ZcashNodeInstanceRequest: object.ZcashNodeInstanceRequest{
 		InstanceRequest: object.InstanceRequest{
 			Name:           data.Instance1.Name,
 			Version:        data.Instance1.Version,
@@ -64,11 +93,9 @@ func Test_CreateZcashInstance(t *testing.T) {
 		TransactionIndex: false,
 		Miner:            false,
 		Peers:            []string{},
-	}
+	}}
 
-	instance, err := zcashResource.CreateInstance(ctx, &project, request)
-	assert.NoError(t, err)
-	assert.NotNil(t, instance)
+	return zcashResource.CreateInstance(ctx, &project, request)
 }
 
 func Test_CreateZcashDeploymentResourceAssets(t *testing.T) {
@@ -80,17 +107,38 @@ func Test_CreateZcashDeploymentResourceAssets(t *testing.T) {
 	appManager := vars.ManagerFactory.GetAppResourceManager(ctx).(*rsc.FakeAppResourceManager)
 
 	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 
 	appManager.FakeCreateVolumeAsset = func(ctx context.Context, volumes ...spec.VolumeSpec) ([]*unstructured.Unstructured, error) {
 		data, err := data.GetInstanceResources(ztypes.InstanceTypeZCASH, ztypes.ResourcePersistentVolumeClaim, 2)
 		return data, err
 	}
 
-	objects, err := zcashResource.CreateDeploymentResourceAssets(ctx, data.Instance1)
+	instance, err := createZcashInstance(ctx, zcashResource)
+	assert.NoError(t, err)
+	zcash := instance.(*ZcashInstance)
+	rpcAuth := append([]string(nil), zcash.RPCAuth...)
+
+	objects, err := zcashResource.CreateDeploymentResourceAssets(ctx, instance)
 	assert.NoError(t, err)
 	assert.NotNil(t, objects)
+	assert.Contains(t, objectKinds(objects), "Secret")
 	//	t.Logf("Objects: %s", utils.MarshalIndentObject(objects))
+
+	// Instances read back from the database keep their credentials Secret.
+	var stored ZcashInstance
+	value, _ := bson.Marshal(zcash)
+	assert.NoError(t, bson.Unmarshal(value, &stored))
+	objects, err = zcashResource.CreateDeploymentResourceAssets(ctx, &stored)
+	assert.NoError(t, err)
+	assert.NotContains(t, objectKinds(objects), "Secret")
+
+	_, err = zcashResource.CreateDeploymentResourceAssets(ctx, instance)
+	assert.NoError(t, err)
+	assert.Equal(t, rpcAuth, zcash.RPCAuth)
+
+	_, err = zcashResource.CreateDeploymentResourceAssets(ctx, data.Instance1)
+	assert.Error(t, err)
 }
 
 func Test_CreateIngressAsset(t *testing.T) {
@@ -106,7 +154,7 @@ func Test_CreateIngressAsset(t *testing.T) {
 	t.Logf("Ingress: %s", utils.MarshalObject(projIngress))
 
 	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 
 	// 1. create instance, 2 routes
 	projIngress, err = zcashResource.CreateIngressAsset(ctx, projIngress, data.Instance1, ztypes.EventActionCreate)
@@ -132,15 +180,108 @@ func Test_CreateZcashStartResourceAssets(t *testing.T) {
 	test.InitTest(ctx)
 
 	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 
-	objects, err := zcashResource.CreateStartResourceAssets(ctx, data.Instance1)
+	instance, err := createZcashInstance(ctx, zcashResource)
 	assert.NoError(t, err)
-	assert.NotNil(t, objects)
+	rpcAuth := append([]string(nil), instance.(*ZcashInstance).RPCAuth...)
+
+	objects, err := zcashResource.CreateStartResourceAssets(ctx, instance)
+	assert.NoError(t, err)
+	assert.Len(t, objects, len(ZCASH_TEMPLATES[startOperation]))
+	assert.Equal(t, rpcAuth, instance.(*ZcashInstance).RPCAuth)
 
 	t.Logf("Objects: %s", utils.MarshalIndentObject(objects))
 }
 
+func Test_CreateZcashRotationAssets(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
+
+	instance, err := createZcashInstance(ctx, zcashResource)
+	assert.NoError(t, err)
+	zcash := instance.(*ZcashInstance)
+	password := zcash.credentials.password
+
+	objects, err := zcashResource.CreateRotationAssets(ctx, instance)
+	assert.NoError(t, err)
+	assert.Len(t, objects, len(ZCASH_TEMPLATES[rotationOperation]))
+	assert.Contains(t, objectKinds(objects), "Secret")
+	assert.NotEqual(t, password, zcash.credentials.password)
+
+	_, err = zcashResource.CreateRotationAssets(ctx, data.Instance1)
+	assert.Error(t, err)
+}
+
+func Test_CreateProjectRotationAssetsFailure(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
+
+	opts := testOptions()
+	opts.Instances = func(ctx context.Context, project string) ([]entity.InstanceIF, error) {
+		return nil, errors.New("instances not available")
+	}
+	projManager, err := NewProjectResourceManager(vars.ResourceConfig.Project,
+		map[ztypes.InstanceType]interfaces.InstanceResourceManagerIF{ztypes.InstanceTypeZCASH: zcashResource}, opts)
+	assert.NoError(t, err)
+
+	instance, err := createZcashInstance(ctx, zcashResource)
+	assert.NoError(t, err)
+	zcash := instance.(*ZcashInstance)
+	rpcAuth := append([]string(nil), zcash.RPCAuth...)
+
+	// The credentials are not recorded when the dependents of the node cannot be rolled over.
+	_, err = projManager.CreateRotationAssets(ctx, instance)
+	assert.Error(t, err)
+	assert.Equal(t, rpcAuth, zcash.RPCAuth)
+}
+
+func Test_CreateZcashCredentialExpiryAssets(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := testOptions()
+	opts.Clock = func() time.Time { return now }
+	opts.CredentialOverlap = time.Hour
+
+	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, opts)
+	expiry := zcashResource.(CredentialExpiryManager)
+
+	instance, err := createZcashInstance(ctx, zcashResource)
+	assert.NoError(t, err)
+	zcash := instance.(*ZcashInstance)
+
+	_, err = zcashResource.CreateRotationAssets(ctx, instance)
+	assert.NoError(t, err)
+	assert.Len(t, zcash.RPCAuth, 2)
+
+	objects, err := expiry.CreateCredentialExpiryAssets(ctx, instance)
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+	assert.Len(t, zcash.RPCAuth, 2)
+
+	now = now.Add(time.Hour)
+	objects, err = expiry.CreateCredentialExpiryAssets(ctx, instance)
+	assert.NoError(t, err)
+	assert.Len(t, objects, len(ZCASH_TEMPLATES[credentialExpiryOperation]))
+	assert.Len(t, zcash.RPCAuth, 1)
+	assert.True(t, zcash.RPCAuthExpires.IsZero())
+
+	conf, _, _ := unstructured.NestedString(objects[0].Object, "data", "zcash.conf")
+	assert.NotContains(t, conf, "rpcauth=")
+}
+
 func Test_CreateZcashSnapshotAssets(t *testing.T) {
 	vars.DATABASE_FACTORY = "memory"
 	ctx := context.Background()
@@ -149,7 +290,7 @@ func Test_CreateZcashSnapshotAssets(t *testing.T) {
 	appManager := vars.ManagerFactory.GetAppResourceManager(ctx).(*rsc.FakeAppResourceManager)
 
 	zcashConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(ztypes.InstanceTypeZCASH)
-	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig)
+	zcashResource, _ := NewZcashInstanceResourceManager(zcashConfig, testOptions())
 
 	appManager.FakeCreateSnapshotAsset = func(ctx context.Context, req *object.SnapshotRequest) ([]*unstructured.Unstructured, error) {
 		return nil, nil
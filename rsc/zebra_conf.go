@@ -0,0 +1,102 @@
+package rsc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zbitech/common/pkg/model/ztypes"
+)
+
+const (
+	ZEBRA_CACHE_DIR = "/var/cache/zebrad-cache"
+)
+
+// ZebraConf generates the zebrad.toml for a zebra node instance.
+type ZebraConf struct {
+	network     ztypes.NetworkType
+	rpcPort     int32
+	peerPort    int32
+	metricsPort int32
+	cacheDir    string
+	peers       []string
+}
+
+func NewZebraConf(network ztypes.NetworkType) *ZebraConf {
+	return &ZebraConf{network: network, cacheDir: ZEBRA_CACHE_DIR}
+}
+
+func (c *ZebraConf) SetRPCPort(port int32) {
+	c.rpcPort = port
+}
+
+func (c *ZebraConf) SetPeerPort(port int32) {
+	c.peerPort = port
+}
+
+func (c *ZebraConf) SetMetricsPort(port int32) {
+	c.metricsPort = port
+}
+
+func (c *ZebraConf) SetCacheDir(dir string) {
+	c.cacheDir = dir
+}
+
+func (c *ZebraConf) AddPeers(peers ...string) {
+	for _, peer := range peers {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			c.peers = append(c.peers, peer)
+		}
+	}
+}
+
+// Network returns the zebrad name of the network, or an error for networks zebrad
+// does not support.
+func (c *ZebraConf) Network() (string, error) {
+	switch c.network {
+	case ztypes.NetworkTypeMain:
+		return "Mainnet", nil
+	case ztypes.NetworkTypeTest:
+		return "Testnet", nil
+	}
+	return "", fmt.Errorf("network %s is not supported by zebra", c.network)
+}
+
+func (c *ZebraConf) Value() (string, error) {
+	network, err := c.Network()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	b.WriteString("[network]\n")
+	fmt.Fprintf(&b, "network = %q\n", network)
+	if c.peerPort > 0 {
+		fmt.Fprintf(&b, "listen_addr = \"0.0.0.0:%d\"\n", c.peerPort)
+	}
+	if len(c.peers) > 0 {
+		quoted := make([]string, len(c.peers))
+		for index, peer := range c.peers {
+			quoted[index] = fmt.Sprintf("%q", peer)
+		}
+		fmt.Fprintf(&b, "initial_%s_peers = [%s]\n", strings.ToLower(network), strings.Join(quoted, ", "))
+	}
+
+	b.WriteString("\n[state]\n")
+	fmt.Fprintf(&b, "cache_dir = %q\n", c.cacheDir)
+
+	if c.rpcPort > 0 {
+		b.WriteString("\n[rpc]\n")
+		fmt.Fprintf(&b, "listen_addr = \"0.0.0.0:%d\"\n", c.rpcPort)
+	}
+
+	if c.metricsPort > 0 {
+		b.WriteString("\n[metrics]\n")
+		fmt.Fprintf(&b, "endpoint_addr = \"0.0.0.0:%d\"\n", c.metricsPort)
+	}
+
+	b.WriteString("\n[tracing]\n")
+	b.WriteString("use_color = false\n")
+
+	return b.String(), nil
+}
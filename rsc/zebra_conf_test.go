@@ -0,0 +1,44 @@
+package rsc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"testing"
+)
+
+func Test_ZebraConfValue(t *testing.T) {
+	conf := NewZebraConf(ztypes.NetworkTypeTest)
+	conf.SetRPCPort(18232)
+	conf.SetPeerPort(18233)
+	conf.SetMetricsPort(9999)
+	conf.AddPeers("zebrad-svc-node1.project.svc.cluster.local:18233", " ")
+
+	value, err := conf.Value()
+	assert.NoError(t, err)
+	assert.Contains(t, value, "network = \"Testnet\"")
+	assert.Contains(t, value, "listen_addr = \"0.0.0.0:18233\"")
+	assert.Contains(t, value, "initial_testnet_peers = [\"zebrad-svc-node1.project.svc.cluster.local:18233\"]")
+	assert.Contains(t, value, "cache_dir = \""+ZEBRA_CACHE_DIR+"\"")
+	assert.Contains(t, value, "[rpc]\nlisten_addr = \"0.0.0.0:18232\"")
+	assert.Contains(t, value, "endpoint_addr = \"0.0.0.0:9999\"")
+}
+
+func Test_ZebraConfMainnet(t *testing.T) {
+	conf := NewZebraConf(ztypes.NetworkTypeMain)
+
+	value, err := conf.Value()
+	assert.NoError(t, err)
+	assert.Contains(t, value, "network = \"Mainnet\"")
+	assert.NotContains(t, value, "[rpc]")
+	assert.NotContains(t, value, "initial_mainnet_peers")
+}
+
+func Test_ZebraConfUnsupportedNetwork(t *testing.T) {
+	conf := NewZebraConf(NetworkTypeRegtest)
+
+	_, err := conf.Network()
+	assert.Error(t, err)
+
+	_, err = conf.Value()
+	assert.Error(t, err)
+}
@@ -0,0 +1,460 @@
+package rsc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/zbitech/common/interfaces"
+	"github.com/zbitech/common/pkg/errs"
+	"github.com/zbitech/common/pkg/logger"
+	"github.com/zbitech/common/pkg/model/config"
+	"github.com/zbitech/common/pkg/model/entity"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/spec"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/rctx"
+	"github.com/zbitech/common/pkg/utils"
+	"github.com/zbitech/mgr/internal/helper"
+	"github.com/zbitech/mgr/internal/ingress"
+	"go.mongodb.org/mongo-driver/bson"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	InstanceTypeZEBRA ztypes.InstanceType = "zebra"
+)
+
+var (
+	ZEBRA_FUNCTIONS = template.FuncMap{
+		"indent": func(spaces int, value string) string {
+			pad := strings.Repeat(" ", spaces)
+			return pad + strings.Replace(strings.TrimRight(value, "\n"), "\n", "\n"+pad, -1)
+		},
+	}
+
+	ZEBRA_TEMPLATES = TemplateContract{
+		deploymentOperation: {"ZEBRA_CONF", "ENVOY_CONF", "DEPLOYMENT", "SERVICE", "NETWORK_POLICY"},
+		startOperation:      {"DEPLOYMENT", "SERVICE"},
+		ingressOperation:    {"INGRESS", "INGRESS_STOPPED", "PROJECT_INGRESS"},
+	}
+)
+
+type ZebraDetails struct {
+	Peers      []string          `json:"peers" bson:"peers"`
+	DataVolume entity.DataVolume `json:"dataVolume" bson:"dataVolume"`
+}
+
+type ZebraInstance struct {
+	entity.Instance `bson:",inline"`
+	ZebraDetails    `bson:",inline"`
+	InstanceDetails `bson:",inline"`
+}
+
+type ZebraNodeInstanceRequest struct {
+	object.InstanceRequest
+	InstanceRequestDetails
+	Peers []string `json:"peers"`
+}
+
+func (z ZebraNodeInstanceRequest) GetInstanceType() ztypes.InstanceType {
+	return InstanceTypeZEBRA
+}
+
+type ZebraNodeInstanceSpec struct {
+	spec.InstanceSpec
+	ZebraConf     string
+	ZebraImage    string
+	Port          int32
+	PeerPort      int32
+	MetricsPort   int32
+	DataVolume    string
+	Envoy         spec.EnvoySpec
+	NetworkPolicy NetworkPolicySpec
+}
+
+type ZebraInstanceResourceManager struct {
+	mu        sync.RWMutex
+	rscConfig *config.InstanceResourceConfig
+	templates map[string]*FileTemplate
+	opts      Options
+}
+
+func NewZebraInstanceResourceManager(instanceConfig *config.InstanceResourceConfig, opts Options) (interfaces.InstanceResourceManagerIF, error) {
+	rscConfig, templates, err := loadInstanceConfig(opts.Assets, instanceConfig, ZEBRA_FUNCTIONS)
+	if err != nil {
+		logger.Errorf(rctx.CTX, "Failed to create manager: %s", err)
+		return nil, err
+	}
+
+	return &ZebraInstanceResourceManager{rscConfig: rscConfig, templates: templates, opts: opts}, nil
+}
+
+func (z *ZebraInstanceResourceManager) config() *config.InstanceResourceConfig {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.rscConfig
+}
+
+func (z *ZebraInstanceResourceManager) fileTemplate(version string) *FileTemplate {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.templates[version]
+}
+
+func (z *ZebraInstanceResourceManager) PrepareReload(ctx context.Context, cfg *config.InstanceResourceConfig) (func(), error) {
+	rscConfig, templates, err := loadInstanceConfig(z.opts.Assets, cfg, ZEBRA_FUNCTIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = z.verification().verify(rscConfig.Versions, templates).Err(); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		z.mu.Lock()
+		defer z.mu.Unlock()
+		z.rscConfig = rscConfig
+		z.templates = templates
+	}, nil
+}
+
+func (z *ZebraInstanceResourceManager) VerifyTemplates(ctx context.Context) TemplateReport {
+	z.mu.RLock()
+	versions, templates := z.rscConfig.Versions, z.templates
+	z.mu.RUnlock()
+
+	return z.verification().verify(versions, templates)
+}
+
+func (z *ZebraInstanceResourceManager) verification() templateVerification {
+	return templateVerification{
+		manager:   string(InstanceTypeZEBRA),
+		contract:  ZEBRA_TEMPLATES,
+		fragments: map[string]bool{"INGRESS": true, "INGRESS_STOPPED": true, "PROJECT_INGRESS": true},
+		sample:    z.sampleSpec,
+	}
+}
+
+func (z *ZebraInstanceResourceManager) sampleSpec(_, version string, instResource *config.VersionedResourceConfig) interface{} {
+	zebra := &ZebraInstance{Instance: sampleInstance(InstanceTypeZEBRA, version)}
+	zebra.DataVolume.Name = "zebra-data"
+
+	zebraSpec := z.createInstanceSpec(zebra)
+	zebraSpec.ZebraImage = imageURL(instResource.GetImage("node"))
+
+	conf := NewZebraConf(zebra.Network)
+	conf.SetRPCPort(zebraSpec.Port)
+	conf.SetPeerPort(zebraSpec.PeerPort)
+	conf.SetMetricsPort(zebraSpec.MetricsPort)
+	// sample instances are always on testnet
+	zebraSpec.ZebraConf, _ = conf.Value()
+
+	return zebraSpec
+}
+
+func (z *ZebraInstanceResourceManager) GetInstanceResources(version string) (*config.VersionedResourceConfig, bool) {
+	resource, ok := z.config().Versions[version]
+	return resource, ok
+}
+
+func (z *ZebraInstanceResourceManager) CreateInstanceRequest(ctx context.Context, iRequest interface{}) (object.InstanceRequestIF, error) {
+
+	jsonStr, err := json.Marshal(iRequest)
+	if err != nil {
+		return nil, errs.ErrMarshalFailed
+	}
+
+	var zebraReq ZebraNodeInstanceRequest
+	if err := json.Unmarshal(jsonStr, &zebraReq); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal request - %s", err)
+		return nil, errs.ErrMarshalFailed
+	}
+
+	return zebraReq, nil
+}
+
+func (z *ZebraInstanceResourceManager) CreateInstance(ctx context.Context, project *entity.Project, request object.InstanceRequestIF) (entity.InstanceIF, error) {
+
+	instResource, ok := z.GetInstanceResources(request.GetVersion())
+	if !ok {
+		logger.Errorf(ctx, "Zebra resource not available for %s", request.GetVersion())
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	zebraRequest := request.(ZebraNodeInstanceRequest)
+
+	var errors ValidationErrors
+	if project.GetNetwork() == NetworkTypeRegtest {
+		errors.Add("network", project.GetNetwork(), "is not supported by zebra instances")
+	}
+	validatePeerAddresses(&errors, zebraRequest.Peers)
+	sizes := z.opts.volumePolicy(z.fileTemplate(zebraRequest.GetVersion())).volumeSizes(&errors, instResource, zebraRequest.VolumeSizes)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Zebra instance request %s is invalid - %s", zebraRequest.GetName(), err)
+		return nil, err
+	}
+
+	dataVolume := instResource.Volumes[0]
+
+	return &ZebraInstance{
+		Instance: entity.Instance{
+			Project:        project.GetName(),
+			Name:           zebraRequest.GetName(),
+			Version:        zebraRequest.GetVersion(),
+			Network:        project.GetNetwork(),
+			Description:    zebraRequest.Description,
+			Owner:          project.GetOwner(),
+			Status:         "New",
+			Timestamp:      z.opts.now(),
+			DataSourceType: zebraRequest.GetDataSourceType(),
+			DataSource:     zebraRequest.GetDataSource(),
+			InstanceType:   zebraRequest.GetInstanceType(),
+			Action:         "created",
+			ActionTime:     z.opts.now(),
+		},
+		ZebraDetails: ZebraDetails{
+			Peers:      zebraRequest.Peers,
+			DataVolume: entity.DataVolume{Name: dataVolume + "-" + zebraRequest.Name, Size: sizes[dataVolume], Volume: dataVolume},
+		},
+	}, nil
+}
+
+func (z *ZebraInstanceResourceManager) UpdateInstance(ctx context.Context, project *entity.Project, instance entity.InstanceIF, request object.InstanceRequestIF) error {
+
+	zebraRequest := request.(ZebraNodeInstanceRequest)
+	zebra := instance.(*ZebraInstance)
+
+	instResource, ok := z.GetInstanceResources(zebra.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zebra resource not available for %s", zebra.Version)
+		return errs.ErrInstanceResourceFailed
+	}
+
+	var errors ValidationErrors
+	validatePeerAddresses(&errors, zebraRequest.Peers)
+	z.opts.volumePolicy(z.fileTemplate(zebra.Version)).resizeVolumes(&errors, instResource, zebraRequest.VolumeSizes, &zebra.DataVolume)
+	if err := errors.Err(); err != nil {
+		logger.Errorf(ctx, "Zebra instance request %s is invalid - %s", zebraRequest.GetName(), err)
+		return err
+	}
+
+	zebra.Action = "updated"
+	zebra.ActionTime = z.opts.now()
+	zebra.Description = zebraRequest.Description
+	zebra.Peers = zebraRequest.Peers
+
+	return nil
+}
+
+func (z *ZebraInstanceResourceManager) createInstanceSpec(zebra *ZebraInstance) ZebraNodeInstanceSpec {
+	rscConfig := z.config()
+	return ZebraNodeInstanceSpec{
+		InstanceSpec: spec.InstanceSpec{
+			Name:               zebra.Name,
+			Project:            zebra.Project,
+			Version:            zebra.Version,
+			ServiceAccountName: helper.CreateServiceAccountName(zebra.GetProject()),
+			Namespace:          zebra.GetNamespace(),
+			Labels:             helper.CreateInstanceLabels(zebra),
+			DomainName:         z.opts.Policy.Domain,
+			DomainSecret:       z.opts.Policy.CertName,
+			DataSourceType:     zebra.DataSourceType,
+			DataSource:         zebra.DataSource},
+		Port:          rscConfig.Ports["service"],
+		PeerPort:      rscConfig.Ports["peer"],
+		MetricsPort:   rscConfig.Ports["metrics"],
+		DataVolume:    zebra.DataVolume.Name,
+		Envoy:         z.opts.envoySpec(zebra.GetNamespace(), rscConfig.Ports["envoy"]),
+		NetworkPolicy: z.opts.networkPolicySpec(),
+	}
+}
+
+func (z *ZebraInstanceResourceManager) CreateDeploymentResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+
+	zebra := instance.(*ZebraInstance)
+	instResource, ok := z.GetInstanceResources(zebra.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zebra resource not available for %s", zebra.Version)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	nodeImage := instResource.GetImage("node")
+	if nodeImage == nil {
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	zebraSpec := z.createInstanceSpec(zebra)
+	zebraSpec.ZebraImage = nodeImage.URL
+
+	conf := NewZebraConf(zebra.Network)
+	conf.SetRPCPort(zebraSpec.Port)
+	conf.SetPeerPort(zebraSpec.PeerPort)
+	conf.SetMetricsPort(zebraSpec.MetricsPort)
+	conf.AddPeers(zebra.Peers...)
+
+	var specArr []string
+	var err error
+
+	if zebraSpec.ZebraConf, err = conf.Value(); err != nil {
+		logger.Errorf(ctx, "Zebra config for instance %s failed - %s", zebra.Name, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	fileTemplate := z.fileTemplate(zebra.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(ZEBRA_TEMPLATES[deploymentOperation], zebraSpec)
+	if err != nil {
+		logger.Errorf(ctx, "Zebra templates for version %s failed - %s", zebra.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	objects, err := helper.CreateYAMLObjects(specArr)
+	if err != nil {
+		logger.Errorf(ctx, "Zebra templates for version %s failed - %s", zebra.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	storageClass := z.opts.Policy.StorageClass
+
+	var volumeSpecs = []spec.VolumeSpec{
+		withVolumeSource(spec.VolumeSpec{Volume: zebra.DataVolume.Volume, VolumeName: zebra.DataVolume.Name, StorageClass: storageClass,
+			Namespace: zebra.GetNamespace(), Size: zebra.DataVolume.Size, Labels: zebraSpec.Labels}, &zebra.Instance, &zebra.InstanceDetails),
+	}
+
+	appRsc := z.opts.appManager(ctx)
+	volumes, err := appRsc.CreateVolumeAsset(ctx, volumeSpecs...)
+	if err != nil {
+		logger.Errorf(ctx, "Zebra volume templates for version %s failed - %s", zebra.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	objects = append(objects, volumes...)
+	return applyInstanceMetadata(instance, objects), nil
+}
+
+func (z *ZebraInstanceResourceManager) CreateStartResourceAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+
+	zebra := instance.(*ZebraInstance)
+	instResource, ok := z.GetInstanceResources(zebra.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zebra resource not available for %s", zebra.Version)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	nodeImage := instResource.GetImage("node")
+	if nodeImage == nil {
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	zebraSpec := z.createInstanceSpec(zebra)
+	zebraSpec.ZebraImage = nodeImage.URL
+
+	var specArr []string
+	var err error
+
+	fileTemplate := z.fileTemplate(zebra.Version)
+	specArr, err = fileTemplate.ExecuteTemplates(ZEBRA_TEMPLATES[startOperation], zebraSpec)
+	if err != nil {
+		logger.Errorf(ctx, "Zebra templates for version %s failed - %s", zebra.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	objects, err := helper.CreateYAMLObjects(specArr)
+	if err != nil {
+		logger.Errorf(ctx, "Zebra templates for version %s failed - %s", zebra.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
+}
+
+func (z *ZebraInstanceResourceManager) CreateIngressAsset(ctx context.Context, projIngress *unstructured.Unstructured, instance entity.InstanceIF, action ztypes.EventAction) (*unstructured.Unstructured, error) {
+
+	zebra := instance.(*ZebraInstance)
+	_, ok := z.GetInstanceResources(zebra.Version)
+	if !ok {
+		logger.Errorf(ctx, "Zebra resource not available for %s", zebra.Version)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	zebraSpec := z.createInstanceSpec(zebra)
+
+	var specObj string
+	var err error
+
+	fileTemplate := z.fileTemplate(zebra.Version)
+	if action == ztypes.EventActionStopInstance {
+		specObj, err = fileTemplate.ExecuteTemplate("INGRESS_STOPPED", zebraSpec)
+	} else {
+		specObj, err = fileTemplate.ExecuteTemplate("INGRESS", zebraSpec)
+	}
+	if err != nil {
+		logger.Errorf(ctx, "Zebra templates for version %s failed - %s", zebra.Version, err)
+		return nil, errs.ErrInstanceResourceFailed
+	}
+
+	return reconcileIngress(ctx, projIngress, ingress.Route, specObj, action, func() (string, error) {
+		return fileTemplate.ExecuteTemplate("PROJECT_INGRESS", zebraSpec)
+	})
+}
+
+func (z *ZebraInstanceResourceManager) CreateSnapshotAssets(ctx context.Context, instance entity.InstanceIF, volume string) ([]*unstructured.Unstructured, error) {
+
+	var req object.SnapshotRequest
+
+	zebra := instance.(*ZebraInstance)
+	appRsc := z.opts.appManager(ctx)
+	req.Namespace = zebra.GetNamespace()
+	req.Volume = volume
+	req.VolumeName = zebra.DataVolume.Name
+	req.Labels = helper.CreateInstanceLabels(zebra)
+
+	objects, err := appRsc.CreateSnapshotAsset(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
+}
+
+func (z *ZebraInstanceResourceManager) CreateSnapshotScheduleAssets(ctx context.Context, instance entity.InstanceIF, volume string, scheduleType ztypes.ZBIBackupScheduleType) ([]*unstructured.Unstructured, error) {
+
+	var req object.SnapshotScheduleRequest
+
+	zebra := instance.(*ZebraInstance)
+	appRsc := z.opts.appManager(ctx)
+	req.Namespace = zebra.GetNamespace()
+	req.Volume = volume
+	req.Schedule = scheduleType
+	req.VolumeName = zebra.DataVolume.Name
+	req.Labels = helper.CreateInstanceLabels(zebra)
+
+	objects, err := appRsc.CreateSnapshotScheduleAsset(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyInstanceMetadata(instance, objects), nil
+}
+
+// CreateRotationAssets returns no assets since the zebra RPC endpoint is only reachable through the envoy proxy
+// and has no credentials of its own.
+func (z *ZebraInstanceResourceManager) CreateRotationAssets(ctx context.Context, instance entity.InstanceIF) ([]*unstructured.Unstructured, error) {
+	return []*unstructured.Unstructured{}, nil
+}
+
+func (z *ZebraInstanceResourceManager) UnmarshalBSONDetails(ctx context.Context, value bson.Raw) (entity.InstanceIF, error) {
+
+	logger.Tracef(ctx, "Unmarshaling Zebra instance details ............. %s", value.String())
+
+	var zebra ZebraInstance
+	if err := bson.Unmarshal(value, &zebra); err != nil {
+		return nil, err
+	}
+
+	logger.Debugf(ctx, "Unmarshaled zebra details - %s", utils.MarshalObject(zebra))
+	return &zebra, nil
+}
@@ -0,0 +1,160 @@
+package rsc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/zbitech/common/pkg/model/object"
+	"github.com/zbitech/common/pkg/model/spec"
+	"github.com/zbitech/common/pkg/model/ztypes"
+	"github.com/zbitech/common/pkg/utils"
+	"github.com/zbitech/common/pkg/vars"
+	"github.com/zbitech/fake/data"
+	"github.com/zbitech/fake/mgr/rsc"
+	"github.com/zbitech/fake/test"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func createZebraInstance(ctx context.Context, t *testing.T) (*ZebraInstance, *ZebraInstanceResourceManager) {
+	zebraConfig, ok := vars.ResourceConfig.GetInstanceResourceConfig(InstanceTypeZEBRA)
+	assert.True(t, ok)
+
+	zebraResource, err := NewZebraInstanceResourceManager(zebraConfig, testOptions())
+	assert.NoError(t, err)
+
+	var request = ZebraNodeInstanceRequest{
+		InstanceRequest: object.InstanceRequest{
+			Name:           "zebra-instance",
+			Version:        "v1",
+			Description:    "Zebra instance",
+			DataSourceType: ztypes.NoDataSource,
+		},
+		Peers: []string{"zebrad-svc-peer.project.svc.cluster.local:18233"},
+	}
+
+	instance, err := zebraResource.CreateInstance(ctx, &data.Project1, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, instance)
+
+	return instance.(*ZebraInstance), zebraResource.(*ZebraInstanceResourceManager)
+}
+
+func Test_NewZebraInstanceResourceManager(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zebraConfig, ok := vars.ResourceConfig.GetInstanceResourceConfig(InstanceTypeZEBRA)
+	assert.Truef(t, ok, "zebra resource not configured")
+
+	zebraResource, err := NewZebraInstanceResourceManager(zebraConfig, testOptions())
+	assert.NoError(t, err)
+	assert.NotNil(t, zebraResource)
+}
+
+func Test_CreateZebraInstanceRequest(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zebraConfig, _ := vars.ResourceConfig.GetInstanceResourceConfig(InstanceTypeZEBRA)
+	zebraResource, _ := NewZebraInstanceResourceManager(zebraConfig, testOptions())
+
+	var input = map[string]interface{}{
+		"name":    "zebra-instance",
+		"version": "v1",
+		"peers":   []string{"zebrad-svc-peer.project.svc.cluster.local:18233"},
+	}
+
+	zebraReq, err := zebraResource.CreateInstanceRequest(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, InstanceTypeZEBRA, zebraReq.GetInstanceType())
+	assert.Len(t, zebraReq.(ZebraNodeInstanceRequest).Peers, 1)
+}
+
+func Test_CreateZebraInstance(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zebra, _ := createZebraInstance(ctx, t)
+	assert.Equal(t, InstanceTypeZEBRA, zebra.GetInstanceType())
+	assert.Equal(t, "zebra-data-zebra-instance", zebra.DataVolume.Name)
+	assert.Len(t, zebra.Peers, 1)
+}
+
+func Test_CreateZebraInstanceInvalidPeers(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	zebra, zebraResource := createZebraInstance(ctx, t)
+
+	var request = ZebraNodeInstanceRequest{
+		InstanceRequest: object.InstanceRequest{
+			Name:           "zebra-instance2",
+			Version:        "v1",
+			DataSourceType: ztypes.NoDataSource,
+		},
+		Peers: []string{"zebrad-svc-peer.project.svc.cluster.local"},
+	}
+
+	_, err := zebraResource.CreateInstance(ctx, &data.Project1, request)
+	assert.Error(t, err)
+
+	request.Name = zebra.Name
+	request.Peers = []string{"zebrad-svc-peer.project.svc.cluster.local:18233\naddnode=attacker"}
+	err = zebraResource.UpdateInstance(ctx, &data.Project1, zebra, request)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"zebrad-svc-peer.project.svc.cluster.local:18233"}, zebra.Peers)
+}
+
+func Test_CreateZebraDeploymentResourceAssets(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	appManager := vars.ManagerFactory.GetAppResourceManager(ctx).(*rsc.FakeAppResourceManager)
+	appManager.FakeCreateVolumeAsset = func(ctx context.Context, volumes ...spec.VolumeSpec) ([]*unstructured.Unstructured, error) {
+		return []*unstructured.Unstructured{}, nil
+	}
+
+	zebra, zebraResource := createZebraInstance(ctx, t)
+	objects, err := zebraResource.CreateDeploymentResourceAssets(ctx, zebra)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 5)
+
+	var policies = 0
+	for _, object := range objects {
+		if object.GetKind() == "NetworkPolicy" {
+			policies++
+
+			rules, _, _ := unstructured.NestedSlice(object.Object, "spec", "ingress")
+			var peerRule = rules[len(rules)-1].(map[string]interface{})
+			ports, _, _ := unstructured.NestedSlice(peerRule, "ports")
+			assert.EqualValues(t, zebraResource.config().Ports["peer"], ports[0].(map[string]interface{})["port"])
+		}
+	}
+	assert.Equal(t, 1, policies)
+
+	t.Logf("Objects: %s", utils.MarshalIndentObject(objects))
+}
+
+func Test_CreateZebraIngressAsset(t *testing.T) {
+	vars.DATABASE_FACTORY = "memory"
+	ctx := context.Background()
+	test.InitTest(ctx)
+
+	projIngress, err := data.GetGenericResource(ztypes.ResourceHTTPProxy)
+	assert.NoError(t, err)
+
+	zebra, zebraResource := createZebraInstance(ctx, t)
+
+	projIngress, err = zebraResource.CreateIngressAsset(ctx, projIngress, zebra, ztypes.EventActionCreate)
+	assert.NoError(t, err)
+	assert.NotNil(t, projIngress)
+
+	projIngress, err = zebraResource.CreateIngressAsset(ctx, projIngress, zebra, ztypes.EventActionDelete)
+	assert.NoError(t, err)
+	assert.NotNil(t, projIngress)
+}